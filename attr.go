@@ -0,0 +1,279 @@
+package dom
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidName is returned when a string is not a legal XML name.
+	ErrInvalidName = errors.New("Invalid name")
+)
+
+// RemoveEmptyAttrs removes the attributes whose Value is an empty string from elem
+// and returns the number of removed attributes. The order of the remaining attributes is preserved.
+func (elem *Element) RemoveEmptyAttrs() (res int) {
+	if elem == nil {
+		return
+	}
+
+	attrs := elem.Attr[:0]
+	for _, attr := range elem.Attr {
+		if len(attr.Value) == 0 {
+			res++
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	elem.Attr = attrs
+
+	return
+}
+
+// RemoveEmptyAttrsRecurse works like RemoveEmptyAttrs, but it also removes empty attributes
+// from all the descendant elements. It returns the total number of removed attributes.
+func (elem *Element) RemoveEmptyAttrsRecurse() (res int) {
+	if elem == nil {
+		return
+	}
+
+	res = elem.RemoveEmptyAttrs()
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			res += childElem.RemoveEmptyAttrsRecurse()
+		}
+	}
+
+	return
+}
+
+// SetAttrSafe works like SetAttr, but it validates name first. It returns ErrInvalidName and leaves
+// elem unchanged if name is not a legal XML name. rawValue is stored as it is since it is escaped
+// when elem is marshaled.
+func (elem *Element) SetAttrSafe(name, rawValue string) error {
+	if isName(name) == false {
+		return ErrInvalidName
+	}
+
+	elem.SetAttr(name, rawValue)
+	return nil
+}
+
+// SetAttr sets the value of the first attribute whose Name.Local is name. The attribute is updated
+// in place to preserve the order of Attr, or appended if elem does not have it yet.
+// Nothing happens if elem is nil.
+func (elem *Element) SetAttr(name, value string) {
+	if elem == nil {
+		return
+	}
+
+	if attr := elem.FindAttr(name); attr != nil {
+		attr.Value = value
+	} else {
+		elem.Attr = append(elem.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	}
+}
+
+// FindAttrNS finds the attribute whose Name.Space is space and Name.Local is local with linear search.
+// space is the namespace URI, e.g. "http://www.w3.org/1999/xlink" for xlink:href, rather than the prefix,
+// and an empty space matches only the attributes without a namespace. It returns nil if there is no such attribute.
+func (elem *Element) FindAttrNS(space, local string) *xml.Attr {
+	if elem == nil {
+		return nil
+	}
+
+	for i := range elem.Attr {
+		if attr := &elem.Attr[i]; attr.Name.Space == space && attr.Name.Local == local {
+			return attr
+		}
+	}
+	return nil
+}
+
+// SetAttrNS works like SetAttr, but it sets the attribute found by FindAttrNS(space, local).
+// space is the namespace URI. When elem is marshaled, the attribute is written with the prefix
+// bound to space in scope, or a prefix like "ns1" is declared on elem if there is none.
+func (elem *Element) SetAttrNS(space, local, value string) {
+	if elem == nil {
+		return
+	}
+
+	if attr := elem.FindAttrNS(space, local); attr != nil {
+		attr.Value = value
+	} else {
+		elem.Attr = append(elem.Attr, xml.Attr{Name: xml.Name{Space: space, Local: local}, Value: value})
+	}
+}
+
+// RemoveAttr removes the first attribute whose Name.Local is name, and reports whether it removed anything.
+func (elem *Element) RemoveAttr(name string) bool {
+	if elem == nil {
+		return false
+	}
+
+	for i := range elem.Attr {
+		if elem.Attr[i].Name.Local == name {
+			elem.Attr = append(elem.Attr[:i], elem.Attr[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// isName reports whether s matches the Name production of XML 1.0 (Fifth Edition).
+func isName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	for i, r := range s {
+		if isNameStartChar(r) == false && (i == 0 || isNameChar(r) == false) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStartChar(r rune) bool {
+	switch {
+	case r == ':' || r == '_' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z':
+	case 0xC0 <= r && r <= 0xD6 || 0xD8 <= r && r <= 0xF6 || 0xF8 <= r && r <= 0x2FF:
+	case 0x370 <= r && r <= 0x37D || 0x37F <= r && r <= 0x1FFF || 0x200C <= r && r <= 0x200D:
+	case 0x2070 <= r && r <= 0x218F || 0x2C00 <= r && r <= 0x2FEF || 0x3001 <= r && r <= 0xD7FF:
+	case 0xF900 <= r && r <= 0xFDCF || 0xFDF0 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0xEFFFF:
+	default:
+		return false
+	}
+	return true
+}
+
+func isNameChar(r rune) bool {
+	switch {
+	case r == '-' || r == '.' || '0' <= r && r <= '9' || r == 0xB7:
+	case 0x300 <= r && r <= 0x36F || 0x203F <= r && r <= 0x2040:
+	default:
+		return isNameStartChar(r)
+	}
+	return true
+}
+
+// OrderedAttrs returns a copy of Attr in document order. Unlike Attr itself, the returned slice
+// can be modified without affecting elem.
+func (elem *Element) OrderedAttrs() []xml.Attr {
+	if elem == nil {
+		return []xml.Attr{}
+	}
+
+	res := make([]xml.Attr, len(elem.Attr))
+	copy(res, elem.Attr)
+	return res
+}
+
+// AttrMap returns a map from Name.Local to Value of the attributes, which makes repeated lookups
+// cheaper than FindAttr for elements with many attributes. The map is a snapshot and is not updated
+// along with Attr. If more than one attribute has the same Name.Local, e.g. in different namespaces,
+// the last one wins, while FindAttr returns the first one. It returns an empty map if elem is nil.
+func (elem *Element) AttrMap() map[string]string {
+	if elem == nil {
+		return map[string]string{}
+	}
+
+	res := make(map[string]string, len(elem.Attr))
+	for _, attr := range elem.Attr {
+		res[attr.Name.Local] = attr.Value
+	}
+	return res
+}
+
+// AttrsInNamespace returns copies of the attributes whose Name.Space is uri in document order.
+func (elem *Element) AttrsInNamespace(uri string) (res []xml.Attr) {
+	if elem == nil {
+		return
+	}
+
+	for _, attr := range elem.Attr {
+		if attr.Name.Space == uri {
+			res = append(res, attr)
+		}
+	}
+	return
+}
+
+// RemoveAttrsInNamespace removes the attributes whose Name.Space is uri and returns the number of
+// removed attributes. The order of the remaining attributes is preserved.
+func (elem *Element) RemoveAttrsInNamespace(uri string) (res int) {
+	if elem == nil {
+		return
+	}
+
+	attrs := elem.Attr[:0]
+	for _, attr := range elem.Attr {
+		if attr.Name.Space == uri {
+			res++
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	elem.Attr = attrs
+
+	return
+}
+
+// SetAttrWhere sets the attribute name to value on elem and each of its descendant elements where
+// pred returns true with SetAttr.
+// It returns the number of modified elements.
+func (elem *Element) SetAttrWhere(pred func(*Element) bool, name, value string) (res int) {
+	if elem == nil {
+		return
+	}
+
+	elem.walk(func(e *Element) error {
+		if pred(e) == true {
+			e.SetAttr(name, value)
+			res++
+		}
+		return nil
+	})
+	return
+}
+
+// AttrInt parses the value of the attribute name as an int.
+// ok is false if elem does not have the attribute or the value cannot be parsed.
+func (elem *Element) AttrInt(name string) (res int, ok bool) {
+	if value, found := elem.GetAttr(name); found == true {
+		var err error
+		res, err = strconv.Atoi(strings.TrimSpace(value))
+		ok = err == nil
+	}
+	return
+}
+
+// AttrBool parses the value of the attribute name as a bool. "true", "false", "1" and "0" are accepted
+// case-insensitively. ok is false if elem does not have the attribute or the value cannot be parsed.
+func (elem *Element) AttrBool(name string) (res bool, ok bool) {
+	if value, found := elem.GetAttr(name); found == true {
+		res, ok = parseBool(value)
+	}
+	return
+}
+
+// AttrFloat parses the value of the attribute name as a float64.
+// ok is false if elem does not have the attribute or the value cannot be parsed.
+func (elem *Element) AttrFloat(name string) (res float64, ok bool) {
+	if value, found := elem.GetAttr(name); found == true {
+		var err error
+		res, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		ok = err == nil
+	}
+	return
+}
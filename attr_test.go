@@ -0,0 +1,220 @@
+package dom
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestRemoveEmptyAttrs(t *testing.T) {
+	elem := Must(`<a x="" y="1" z="" w="2"><b v=""><c u=""/></b></a>`)
+	if n := elem.RemoveEmptyAttrs(); n != 2 {
+		t.Fatalf(`elem.RemoveEmptyAttrs() == %d`, n)
+	}
+	if len(elem.Attr) != 2 || elem.Attr[0].Name.Local != "y" || elem.Attr[1].Name.Local != "w" {
+		t.Fatal(`remaining attributes must keep their order`)
+	}
+	if n := elem.RemoveEmptyAttrsRecurse(); n != 2 {
+		t.Fatalf(`elem.RemoveEmptyAttrsRecurse() == %d`, n)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a y="1" w="2"><b><c></c></b></a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.RemoveEmptyAttrs() != 0 || elem.RemoveEmptyAttrsRecurse() != 0 {
+		t.Fatal(`nil elem must return 0`)
+	}
+}
+
+func TestSetAttrSafe(t *testing.T) {
+	elem := Must(`<a x="1"/>`)
+	if err := elem.SetAttrSafe("y", `"<&>"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := elem.SetAttrSafe("x", "2"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "1y", "a b", "a>", "-z", `q"`} {
+		if err := elem.SetAttrSafe(name, "v"); err != ErrInvalidName {
+			t.Fatalf(`SetAttrSafe(%q) must return ErrInvalidName`, name)
+		}
+	}
+	if res, _ := elem.Marshal(true, true); res != `<a x="2" y="&#34;&lt;&amp;&gt;&#34;"></a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if err := elem.SetAttrSafe("x", "1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttrMap(t *testing.T) {
+	elem := Must(`<a xmlns:x="urn:x" id="1" x:id="2" class="c"/>`)
+	res := elem.AttrMap()
+	if len(res) != 3 || res["id"] != "2" || res["class"] != "c" || res["x"] != "urn:x" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res = elem.AttrMap(); res == nil || len(res) != 0 {
+		t.Fatal(`nil elem must return an empty map`)
+	}
+}
+
+func TestOrderedAttrs(t *testing.T) {
+	elem := Must(`<a z="1" y="2" x="3"/>`)
+	attrs := elem.OrderedAttrs()
+	if len(attrs) != 3 || attrs[0].Name.Local != "z" || attrs[1].Name.Local != "y" || attrs[2].Name.Local != "x" {
+		t.Fatal(`attributes must be in document order`)
+	}
+
+	attrs[0].Value = "changed"
+	if elem.Attr[0].Value != "1" {
+		t.Fatal(`OrderedAttrs() must return a copy`)
+	}
+
+	elem = nil
+	if attrs = elem.OrderedAttrs(); attrs == nil || len(attrs) != 0 {
+		t.Fatal(`nil elem must return an empty slice`)
+	}
+}
+
+func TestAttrsInNamespace(t *testing.T) {
+	elem := Must(`<a xmlns:x="urn:x" x:p="1" q="2" x:r="3"/>`)
+	attrs := elem.AttrsInNamespace("urn:x")
+	if len(attrs) != 2 || attrs[0].Name.Local != "p" || attrs[1].Name.Local != "r" {
+		t.Fatal(attrs)
+	}
+
+	if n := elem.RemoveAttrsInNamespace("urn:x"); n != 2 {
+		t.Fatalf(`RemoveAttrsInNamespace() == %d`, n)
+	}
+	if len(elem.Attr) != 2 || elem.Attr[0].Name.Local != "x" || elem.Attr[1].Name.Local != "q" {
+		t.Fatal(elem.Attr)
+	}
+	if len(attrs) != 2 || attrs[1].Name.Local != "r" {
+		t.Fatal(`AttrsInNamespace() must return copies`)
+	}
+
+	elem = nil
+	if len(elem.AttrsInNamespace("urn:x")) != 0 || elem.RemoveAttrsInNamespace("urn:x") != 0 {
+		t.Fatal(`nil elem must return an empty result`)
+	}
+}
+
+func TestSetAttrWhere(t *testing.T) {
+	elem := Must(`<list><item/><group><item processed="false"/><other/></group></list>`)
+	n := elem.SetAttrWhere(func(e *Element) bool {
+		return e.Name.Local == "item"
+	}, "processed", "true")
+
+	if n != 2 {
+		t.Fatalf(`SetAttrWhere() == %d`, n)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<list><item processed="true"></item><group><item processed="true"></item><other></other></group></list>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.SetAttrWhere(func(e *Element) bool { return true }, "x", "y") != 0 {
+		t.Fatal(`nil elem must return 0`)
+	}
+}
+
+func TestSetRemoveAttr(t *testing.T) {
+	elem := Must(`<a x="1" y="2" z="3"/>`)
+	elem.SetAttr("x", "10")
+	elem.SetAttr("w", "4")
+	if res, _ := elem.Marshal(false, false); res != `<a x="10" y="2" z="3" w="4"></a>` {
+		t.Fatal(res)
+	}
+
+	if elem.RemoveAttr("y") == false {
+		t.Fatal(`elem.RemoveAttr("y") == false`)
+	}
+	if elem.RemoveAttr("y") == true {
+		t.Fatal(`elem.RemoveAttr("y") == true`)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a x="10" z="3" w="4"></a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	elem.SetAttr("x", "1")
+	if elem.RemoveAttr("x") == true {
+		t.Fatal(`nil elem must do nothing`)
+	}
+}
+
+func TestAttrTyped(t *testing.T) {
+	elem := Must(`<a i="42" b1="TRUE" b2="0" f="-1.5e2" badi="4x" badb="yes" badf="1.2.3" empty=""/>`)
+
+	if v, ok := elem.AttrInt("i"); ok == false || v != 42 {
+		t.Fatal(`AttrInt("i") failed`)
+	}
+	if v, ok := elem.AttrBool("b1"); ok == false || v != true {
+		t.Fatal(`AttrBool("b1") failed`)
+	}
+	if v, ok := elem.AttrBool("b2"); ok == false || v != false {
+		t.Fatal(`AttrBool("b2") failed`)
+	}
+	if v, ok := elem.AttrFloat("f"); ok == false || v != -150 {
+		t.Fatal(`AttrFloat("f") failed`)
+	}
+
+	for _, name := range []string{"badi", "badb", "badf", "empty", "missing"} {
+		if _, ok := elem.AttrInt(name); ok == true {
+			t.Fatalf(`AttrInt(%q) must fail`, name)
+		}
+		if _, ok := elem.AttrBool(name); ok == true {
+			t.Fatalf(`AttrBool(%q) must fail`, name)
+		}
+		if _, ok := elem.AttrFloat(name); ok == true {
+			t.Fatalf(`AttrFloat(%q) must fail`, name)
+		}
+	}
+
+	elem = nil
+	if _, ok := elem.AttrInt("i"); ok == true {
+		t.Fatal(`nil elem must fail`)
+	}
+}
+
+func TestAttrNS(t *testing.T) {
+	const xlink = "http://www.w3.org/1999/xlink"
+
+	elem := Must(`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href="#x" href="#y"/></svg>`)
+	a := elem.FindChild("a")
+	if attr := a.FindAttrNS(xlink, "href"); attr == nil || attr.Value != "#x" {
+		t.Fatal(attr)
+	}
+	if attr := a.FindAttrNS("", "href"); attr == nil || attr.Value != "#y" {
+		t.Fatal(attr)
+	}
+	if attr := a.FindAttrNS("xlink", "href"); attr != nil {
+		t.Fatal(`space must be the namespace URI`)
+	}
+
+	a.SetAttrNS(xlink, "href", "#z")
+	a.SetAttrNS(xlink, "title", "t")
+	if res, _ := elem.Marshal(false, false); res != `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href="#z" href="#y" xlink:title="t"></a></svg>` {
+		t.Fatal(res)
+	}
+
+	elem = &Element{Name: xml.Name{Local: "use"}}
+	elem.SetAttrNS(xlink, "href", "#x")
+	res, _ := elem.Marshal(false, false)
+	if res != `<use xmlns:ns1="http://www.w3.org/1999/xlink" ns1:href="#x"></use>` {
+		t.Fatal(res)
+	}
+	if attr := Must(res).FindAttrNS(xlink, "href"); attr == nil || attr.Value != "#x" {
+		t.Fatal(`the prefix must be declared`)
+	}
+
+	elem = nil
+	elem.SetAttrNS(xlink, "href", "#x")
+	if elem.FindAttrNS(xlink, "href") != nil {
+		t.Fatal(`nil must not have attributes`)
+	}
+}
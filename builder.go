@@ -0,0 +1,29 @@
+package dom
+
+import "encoding/xml"
+
+// NewElement returns a new element whose Name.Local is name. It is intended to be chained with
+// the With* methods to build trees, e.g.
+//
+//	NewElement("a").WithAttr("id", "1").WithChild(NewElement("b").WithText("text"))
+func NewElement(name string) *Element {
+	return &Element{Name: xml.Name{Local: name}}
+}
+
+// WithAttr sets an attribute with SetAttr and returns elem.
+func (elem *Element) WithAttr(name, value string) *Element {
+	elem.SetAttr(name, value)
+	return elem
+}
+
+// WithText replaces the children with a text with SetText and returns elem.
+func (elem *Element) WithText(s string) *Element {
+	elem.SetText(s)
+	return elem
+}
+
+// WithChild appends child with AppendChild and returns elem. Nothing is appended if child is nil.
+func (elem *Element) WithChild(child *Element) *Element {
+	elem.AppendChild(child)
+	return elem
+}
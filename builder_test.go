@@ -0,0 +1,50 @@
+package dom
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	elem := NewElement("PropertyGroup").
+		WithAttr("Condition", "'$(CompileConfig)' == 'DEBUG'").
+		WithChild(NewElement("Optimization").WithText("false")).
+		WithChild(NewElement("OutputPath").WithAttr("x", "1").WithAttr("x", "2").WithText("bin"))
+
+	res, err := elem.Marshal(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<PropertyGroup Condition="'$(CompileConfig)' == 'DEBUG'"><Optimization>false</Optimization><OutputPath x="2">bin</OutputPath></PropertyGroup>` {
+		t.Fatal(res)
+	}
+
+	child := elem.Children[1].(*Element)
+	if child.Parent != elem {
+		t.Fatal(`WithChild must maintain Parent`)
+	}
+
+	if res, _ = NewElement("a").WithChild(nil).WithText("x").Marshal(false, false); res != `<a>x</a>` {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalNilChild(t *testing.T) {
+	var nilElem *Element
+	elem := &Element{Name: xml.Name{Local: "a"}, Children: []Node{nilElem, xml.CharData("x")}}
+
+	if res, err := elem.Marshal(false, false); err != nil || res != `<a>x</a>` {
+		t.Fatal(res)
+	}
+	if b, err := xml.Marshal(elem); err != nil || string(b) != `<a>x</a>` {
+		t.Fatal(string(b))
+	}
+	var w strings.Builder
+	if _, err := elem.WriteOpts(&w, MarshalOptions{Indent: " "}); err != nil || w.String() != `<a>x</a>` {
+		t.Fatal(w.String())
+	}
+	if res, err := elem.CanonicalXML(); err != nil || string(res) != `<a>x</a>` {
+		t.Fatal(string(res))
+	}
+}
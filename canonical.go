@@ -0,0 +1,124 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"strings"
+)
+
+// CanonicalXML returns the canonical form of elem according to the core of W3C Canonical XML 1.0
+// without comments (https://www.w3.org/TR/xml-c14n), which is suitable for XML digital signatures.
+//
+//   - Empty elements are written as pairs of start and end tags.
+//   - Namespace declarations are written first sorted by prefix, and the ones which are already in effect
+//     in the output are omitted. The other attributes follow sorted by Name.Space then Name.Local.
+//   - Attribute values are quoted with '"', and texts and attribute values are escaped as the specification requires.
+//   - CDATA sections are written as escaped texts.
+//   - Comments and directives are removed. Whitespaces in texts are kept as they are.
+//
+// It returns nil if elem is nil.
+func (elem *Element) CanonicalXML() ([]byte, error) {
+	if elem == nil {
+		return nil, nil
+	}
+
+	c := &canonicalizer{}
+	c.element(elem, map[string]string{})
+	return c.buf.Bytes(), nil
+}
+
+type canonicalizer struct {
+	buf bytes.Buffer
+	ns  nsScope
+}
+
+// canonicalAttr is an attribute with its qualified name.
+type canonicalAttr struct {
+	name  xml.Name
+	qname string
+	value string
+}
+
+// element writes elem where rendered holds the namespace declarations in effect in the output.
+func (c *canonicalizer) element(elem *Element, rendered map[string]string) {
+	start, mark := c.ns.push(elem)
+	defer c.ns.pop(mark)
+
+	// push keeps the order of the attributes other than namespace declarations.
+	var attrs []xml.Attr
+	for i := range elem.Attr {
+		if _, ok := declaration(&elem.Attr[i]); ok == false {
+			attrs = append(attrs, elem.Attr[i])
+		}
+	}
+
+	var decls, others []canonicalAttr
+	for _, attr := range start.Attr {
+		qname := attr.Name.Local
+		if qname == xmlnsPrefix || strings.HasPrefix(qname, xmlnsPrefix+":") == true {
+			prefix := strings.TrimPrefix(strings.TrimPrefix(qname, xmlnsPrefix), ":")
+			if rendered[prefix] == attr.Value {
+				continue
+			}
+			decls = append(decls, canonicalAttr{xml.Name{Local: prefix}, qname, attr.Value})
+		} else {
+			others = append(others, canonicalAttr{attrs[0].Name, qname, attr.Value})
+			attrs = attrs[1:]
+		}
+	}
+
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].name.Local < decls[j].name.Local
+	})
+	sort.Slice(others, func(i, j int) bool {
+		if others[i].name.Space != others[j].name.Space {
+			return others[i].name.Space < others[j].name.Space
+		}
+		return others[i].name.Local < others[j].name.Local
+	})
+
+	if len(decls) > 0 {
+		inner := make(map[string]string, len(rendered)+len(decls))
+		for prefix, uri := range rendered {
+			inner[prefix] = uri
+		}
+		for _, decl := range decls {
+			inner[decl.name.Local] = decl.value
+		}
+		rendered = inner
+	}
+
+	c.buf.WriteByte('<')
+	c.buf.WriteString(start.Name.Local)
+	for _, attr := range append(decls, others...) {
+		c.buf.WriteByte(' ')
+		c.buf.WriteString(attr.qname)
+		c.buf.WriteString(`="`)
+		canonicalAttrReplacer.WriteString(&c.buf, attr.value)
+		c.buf.WriteByte('"')
+	}
+	c.buf.WriteByte('>')
+
+	for _, child := range elem.Children {
+		switch node := child.(type) {
+		case *Element:
+			if node != nil {
+				c.element(node, rendered)
+			}
+		case xml.CharData:
+			canonicalTextReplacer.WriteString(&c.buf, string(node))
+		case CData:
+			canonicalTextReplacer.WriteString(&c.buf, string(node))
+		}
+	}
+
+	c.buf.WriteString("</")
+	c.buf.WriteString(start.Name.Local)
+	c.buf.WriteByte('>')
+}
+
+var (
+	canonicalTextReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	canonicalAttrReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
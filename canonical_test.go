@@ -0,0 +1,29 @@
+package dom
+
+import "testing"
+
+func TestCanonicalXML(t *testing.T) {
+	input := `<doc b="2" a="1" xmlns:z="urn:z" xmlns:a="urn:a" z:x="3" a:y="4"><e/><f xmlns:a="urn:a" v="&quot;&#x9;">t &amp; &lt;&gt; &quot;</f><!--comment--><g xmlns=""/></doc>`
+	res, err := Must(input).CanonicalXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<doc xmlns:a="urn:a" xmlns:z="urn:z" a="1" b="2" a:y="4" z:x="3"><e></e><f v="&quot;&#x9;">t &amp; &lt;&gt; "</f><g></g></doc>`
+	if string(res) != expected {
+		t.Fatal(string(res))
+	}
+
+	elem := &Element{}
+	if err = UnmarshalWith([]byte(`<a xmlns="urn:x"><b xmlns="urn:y"><![CDATA[<c/>]]></b><c xmlns=""/></a>`), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ = elem.CanonicalXML(); string(res) != `<a xmlns="urn:x"><b xmlns="urn:y">&lt;c/&gt;</b><c xmlns=""></c></a>` {
+		t.Fatal(string(res))
+	}
+
+	elem = nil
+	if res, err = elem.CanonicalXML(); res != nil || err != nil {
+		t.Fatal(`nil must be encoded as nil`)
+	}
+}
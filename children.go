@@ -0,0 +1,165 @@
+package dom
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidComment is returned when a comment text contains "--" or ends with '-', which XML does not allow.
+	ErrInvalidComment = errors.New("Invalid comment")
+)
+
+// ChildStats returns the numbers of the child elements, texts (xml.CharData and CData) and comments in Children.
+// Any other nodes such as xml.Directive are not counted.
+func (elem *Element) ChildStats() (elements, texts, comments int) {
+	if elem == nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		switch child.(type) {
+		case *Element:
+			elements++
+		case xml.CharData, CData:
+			texts++
+		case xml.Comment:
+			comments++
+		}
+	}
+	return
+}
+
+// ChildElements returns the child elements in Children in order, skipping texts, comments and directives.
+// It returns nil if elem is nil or has no child elements.
+func (elem *Element) ChildElements() (res []*Element) {
+	if elem == nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			res = append(res, childElem)
+		}
+	}
+	return
+}
+
+// CountChildren returns the number of the child elements. Unlike len(Children), texts, comments and
+// directives are not counted.
+func (elem *Element) CountChildren() int {
+	elements, _, _ := elem.ChildStats()
+	return elements
+}
+
+// CountChildrenNamed returns the number of the child elements whose Name.Local is name.
+func (elem *Element) CountChildrenNamed(name string) (res int) {
+	if elem == nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true && childElem.Name.Local == name {
+			res++
+		}
+	}
+	return
+}
+
+// ChildText returns the plain text (see Text) of the first child element whose Name.Local is name.
+// It returns def if there is no such child or the child does not have a plain text.
+func (elem *Element) ChildText(name, def string) string {
+	if text, ok := elem.FindChild(name).Text(); ok == true {
+		return text
+	}
+	return def
+}
+
+// SelectText returns the plain text (see Text) of the first child element whose Name.Local is name and true.
+// It returns an empty string and false if there is no such child or the child does not have a plain text.
+// Unlike ChildText, it tells a missing text from an empty default.
+func (elem *Element) SelectText(name string) (string, bool) {
+	return elem.FindChild(name).Text()
+}
+
+// PathText descends from elem through the first child element whose Name.Local is names[i] at each level,
+// and returns the plain text (see Text) of the last one, e.g. PathText("owner", "name") for
+// <record><owner><name>x</name></owner></record>. Only the first match is followed at each level, so it
+// does not try the following siblings even if the first one lacks the next level. It returns an empty string
+// and false if any level is missing or the last element does not have a plain text. See XPath for the others.
+func (elem *Element) PathText(names ...string) (string, bool) {
+	for _, name := range names {
+		elem = elem.FindChild(name)
+	}
+	return elem.Text()
+}
+
+// FindChild returns the first child element whose Name.Local is name, or nil if there is no such child.
+func (elem *Element) FindChild(name string) *Element {
+	return elem.FindChildPred(func(child *Element) bool {
+		return child.Name.Local == name
+	})
+}
+
+// FindChildFold works like FindChild, but Name.Local is compared with name case-insensitively.
+// Only ASCII letters are folded in the same manner as ForEachChildNamedFold.
+func (elem *Element) FindChildFold(name string) *Element {
+	return elem.FindChildPred(func(child *Element) bool {
+		return equalFoldASCII(child.Name.Local, name)
+	})
+}
+
+// FindChildPred returns the first child element where pred returns true, or nil if there is no such child.
+func (elem *Element) FindChildPred(pred func(child *Element) bool) *Element {
+	if elem == nil {
+		return nil
+	}
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true && pred(childElem) == true {
+			return childElem
+		}
+	}
+	return nil
+}
+
+// AppendComment appends an xml.Comment child whose text is text, which is written as "<!--text-->".
+// It returns ErrInvalidComment without appending anything if text contains "--" or ends with '-'.
+func (elem *Element) AppendComment(text string) error {
+	if strings.Contains(text, "--") == true || strings.HasSuffix(text, "-") == true {
+		return ErrInvalidComment
+	}
+
+	elem.AppendChild(xml.Comment(text))
+	return nil
+}
+
+// Comments returns the texts of the comments in Children in order. Comments in the descendants are not included.
+func (elem *Element) Comments() (res []string) {
+	if elem == nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		if comment, ok := child.(xml.Comment); ok == true {
+			res = append(res, string(comment))
+		}
+	}
+	return
+}
+
+// Directives returns the texts of the directives in Children in order, e.g. "DOCTYPE html" for <!DOCTYPE html>.
+// See Document for the directives preceding the root element.
+func (elem *Element) Directives() (res []string) {
+	if elem == nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		if directive, ok := child.(xml.Directive); ok == true {
+			res = append(res, string(directive))
+		}
+	}
+	return
+}
@@ -0,0 +1,179 @@
+package dom
+
+import "testing"
+
+func TestChildStats(t *testing.T) {
+	elem := Must(`<a><b/>text<!--comment--><c/><!DOCTYPE x>text<!--comment--><d/></a>`)
+	if elements, texts, comments := elem.ChildStats(); elements != 3 || texts != 2 || comments != 2 {
+		t.Fatal(elements, texts, comments)
+	}
+
+	elem = nil
+	if elements, texts, comments := elem.ChildStats(); elements != 0 || texts != 0 || comments != 0 {
+		t.Fatal(elements, texts, comments)
+	}
+}
+
+func TestChildElements(t *testing.T) {
+	elem := Must(`<a>text<b/><!--comment--><c><d/></c>text<b/></a>`)
+	res := elem.ChildElements()
+	if len(res) != 3 || res[0].Name.Local != "b" || res[1].Name.Local != "c" || res[2] != elem.Children[5] {
+		t.Fatal(res)
+	}
+
+	if res := Must(`<a>text</a>`).ChildElements(); res != nil {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res := elem.ChildElements(); res != nil {
+		t.Fatal(res)
+	}
+}
+
+func TestCountChildren(t *testing.T) {
+	elem := Must(`<a><b/>text<c/></a>`)
+	if res := elem.CountChildren(); res != 2 {
+		t.Fatal(res)
+	}
+
+	elem = Must(`<a><b/><!--comment--><c><b/></c><b/></a>`)
+	if res := elem.CountChildrenNamed("b"); res != 2 {
+		t.Fatal(res)
+	}
+	if res := elem.CountChildrenNamed("z"); res != 0 {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.CountChildren() != 0 || elem.CountChildrenNamed("b") != 0 {
+		t.Fatal(`nil must not have children`)
+	}
+}
+
+func TestChildText(t *testing.T) {
+	elem := Must(`<config><host>example.com</host><port>8080</port><port>9090</port><empty/><mixed>a<b/></mixed></config>`)
+
+	if res := elem.ChildText("host", "localhost"); res != "example.com" {
+		t.Fatal(res)
+	}
+	if res := elem.ChildText("port", "80"); res != "8080" {
+		t.Fatal(res)
+	}
+	for _, name := range []string{"missing", "empty", "mixed"} {
+		if res := elem.ChildText(name, "def"); res != "def" {
+			t.Fatal(res)
+		}
+	}
+
+	elem = nil
+	if res := elem.ChildText("host", "def"); res != "def" {
+		t.Fatal(res)
+	}
+}
+
+func TestFindChild(t *testing.T) {
+	elem := Must(`<a>text<b id="1"/><c><d/></c><b id="2"/></a>`)
+
+	if res := elem.FindChild("b"); res == nil || res.FindAttr("id").Value != "1" {
+		t.Fatal(`elem.FindChild("b") must return the first <b>`)
+	}
+	if res := elem.FindChild("d"); res != nil {
+		t.Fatal(`elem.FindChild("d") != nil`)
+	}
+
+	res := elem.FindChildPred(func(child *Element) bool {
+		id, _ := child.GetAttr("id")
+		return id == "2"
+	})
+	if res == nil || res.Name.Local != "b" {
+		t.Fatal(`elem.FindChildPred() must return the second <b>`)
+	}
+
+	elem = nil
+	if res := elem.FindChild("b"); res != nil {
+		t.Fatal(`nil.FindChild("b") != nil`)
+	}
+}
+
+func TestAppendComment(t *testing.T) {
+	elem := Must(`<a><!-- license --><b><!--nested--></b></a>`)
+	if err := elem.AppendComment(" generated - do not edit "); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"a--b", "trailing-"} {
+		if err := elem.AppendComment(text); err != ErrInvalidComment {
+			t.Fatal(text)
+		}
+	}
+
+	if res, _ := elem.Marshal(false, false); res != `<a><!-- license --><b><!--nested--></b><!-- generated - do not edit --></a>` {
+		t.Fatal(res)
+	}
+
+	res := elem.Comments()
+	if len(res) != 2 || res[0] != " license " || res[1] != " generated - do not edit " {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.AppendComment("x") != nil || elem.Comments() != nil {
+		t.Fatal(`nil must not have comments`)
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	elem := Must(`<a><!DOCTYPE x><b><!ENTITY y "z"></b><!ATTLIST a id ID #IMPLIED></a>`)
+	res := elem.Directives()
+	if len(res) != 2 || res[0] != "DOCTYPE x" || res[1] != "ATTLIST a id ID #IMPLIED" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.Directives() != nil {
+		t.Fatal(`nil must not have directives`)
+	}
+}
+
+func TestSelectText(t *testing.T) {
+	elem := Must(`<r><name>x</name><name>y</name><multi>a<!--c-->b</multi><empty/><nested><name>z</name></nested></r>`)
+
+	if res, ok := elem.SelectText("name"); ok == false || res != "x" {
+		t.Fatal(res)
+	}
+	for _, name := range []string{"missing", "multi", "empty", "nested"} {
+		if res, ok := elem.SelectText(name); ok == true || len(res) > 0 {
+			t.Fatal(name)
+		}
+	}
+
+	elem = nil
+	if _, ok := elem.SelectText("name"); ok == true {
+		t.Fatal(`nil must not have children`)
+	}
+}
+
+func TestPathText(t *testing.T) {
+	elem := Must(`<root><record><owner><name>x</name><address><city>z</city></address></owner><owner><name>y</name></owner></record></root>`)
+
+	if res, ok := elem.PathText("record", "owner", "name"); ok == false || res != "x" {
+		t.Fatal(res)
+	}
+	if res, ok := elem.PathText("record", "owner", "address", "city"); ok == false || res != "z" {
+		t.Fatal(res)
+	}
+	if res, ok := Must(`<a>text</a>`).PathText(); ok == false || res != "text" {
+		t.Fatal(res)
+	}
+	for _, names := range [][]string{{"record", "missing", "name"}, {"record", "owner"}, {"record", "owner", "name", "x"}} {
+		if res, ok := elem.PathText(names...); ok == true || len(res) > 0 {
+			t.Fatal(names)
+		}
+	}
+
+	// Only the first <owner> is followed.
+	elem = Must(`<record><owner><id/></owner><owner><name>x</name></owner></record>`)
+	if _, ok := elem.PathText("owner", "name"); ok == true {
+		t.Fatal(`PathText must follow only the first match`)
+	}
+}
@@ -0,0 +1,149 @@
+package dom
+
+import "encoding/xml"
+
+// MatchesTemplate returns true if elem contains at least the content specified by tmpl.
+// Extra content in elem is allowed while missing content makes the match fail.
+//
+// The matching rules are as follows:
+//   - Name.Local must be equal. Name.Space is compared only when tmpl specifies one.
+//   - Every attribute of tmpl must exist in elem with the same value (attribute subset).
+//   - The element and text children of tmpl must appear in elem.Children in the same
+//     order, though not necessarily contiguously (child subsequence). Element children
+//     are matched recursively with MatchesTemplate and text children by equal content.
+//   - Comments and directives in tmpl are ignored.
+//
+// A nil tmpl matches anything, while a nil elem matches only a nil tmpl.
+func (elem *Element) MatchesTemplate(tmpl *Element) bool {
+	if tmpl == nil {
+		return true
+	}
+
+	if elem == nil || elem.Name.Local != tmpl.Name.Local {
+		return false
+	}
+
+	if len(tmpl.Name.Space) > 0 && elem.Name.Space != tmpl.Name.Space {
+		return false
+	}
+
+	for _, attr := range tmpl.Attr {
+		if found := elem.FindAttr(attr.Name.Local); found == nil || found.Value != attr.Value {
+			return false
+		}
+	}
+
+	i, n := 0, len(elem.Children)
+	for _, want := range tmpl.Children {
+		switch want.(type) {
+		case *Element, xml.CharData:
+		default:
+			continue
+		}
+
+		for ; i < n; i++ {
+			if matchesTemplateNode(elem.Children[i], want) {
+				break
+			}
+		}
+
+		if i == n {
+			return false
+		}
+		i++
+	}
+
+	return true
+}
+
+func matchesTemplateNode(node, want Node) bool {
+	switch want := want.(type) {
+	case *Element:
+		if elem, ok := node.(*Element); ok == true {
+			return elem.MatchesTemplate(want)
+		}
+	case xml.CharData:
+		if text, ok := node.(xml.CharData); ok == true {
+			return string(text) == string(want)
+		}
+	}
+	return false
+}
+
+// Equal returns true if elem and other have the same Name, the same set of attributes and equal
+// Children in the same order. The order of attributes does not matter.
+// Child elements are compared recursively, and the other nodes by their type and string content,
+// where xml.CharData and CData are both regarded as text.
+//
+// Two nil elements are equal, while nil and non-nil are not.
+func (elem *Element) Equal(other *Element) bool {
+	return elem.equal(other, false)
+}
+
+// EqualOrdered works like Equal, but the attributes must appear in the same order.
+func (elem *Element) EqualOrdered(other *Element) bool {
+	return elem.equal(other, true)
+}
+
+func (elem *Element) equal(other *Element, ordered bool) bool {
+	if elem == nil || other == nil {
+		return elem == other
+	}
+
+	if elem.Name != other.Name || len(elem.Attr) != len(other.Attr) || len(elem.Children) != len(other.Children) {
+		return false
+	}
+
+	if ordered == true {
+		for i := range elem.Attr {
+			if elem.Attr[i] != other.Attr[i] {
+				return false
+			}
+		}
+	} else {
+		used := make([]bool, len(other.Attr))
+		for _, attr := range elem.Attr {
+			found := false
+			for j := range other.Attr {
+				if used[j] == false && other.Attr[j] == attr {
+					used[j], found = true, true
+					break
+				}
+			}
+			if found == false {
+				return false
+			}
+		}
+	}
+
+	for i := range elem.Children {
+		if equalNode(elem.Children[i], other.Children[i], ordered) == false {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalNode(a, b Node, ordered bool) bool {
+	if s, ok := isText(a); ok == true {
+		t, ok := isText(b)
+		return ok == true && s == t
+	}
+
+	switch a := a.(type) {
+	case *Element:
+		if b, ok := b.(*Element); ok == true {
+			return a.equal(b, ordered)
+		}
+	case xml.Comment:
+		if b, ok := b.(xml.Comment); ok == true {
+			return string(a) == string(b)
+		}
+	case xml.Directive:
+		if b, ok := b.(xml.Directive); ok == true {
+			return string(a) == string(b)
+		}
+	}
+	return false
+}
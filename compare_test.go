@@ -0,0 +1,74 @@
+package dom
+
+import "testing"
+
+func TestMatchesTemplate(t *testing.T) {
+	elem := Must(`<a id="1" class="x"><b>text</b><!--comment--><c/><d n="1"><e/></d></a>`)
+
+	if elem.MatchesTemplate(Must(`<a/>`)) == false {
+		t.Fatal(`elem.MatchesTemplate(<a/>) == false`)
+	}
+	if elem.MatchesTemplate(Must(`<a class="x"><b>text</b><d><e/></d></a>`)) == false {
+		t.Fatal(`subset template must match`)
+	}
+	if elem.MatchesTemplate(Must(`<a><d/><b/></a>`)) == true {
+		t.Fatal(`children out of order must not match`)
+	}
+	if elem.MatchesTemplate(Must(`<a class="y"/>`)) == true {
+		t.Fatal(`different attribute value must not match`)
+	}
+	if elem.MatchesTemplate(Must(`<a><b>other</b></a>`)) == true {
+		t.Fatal(`different text must not match`)
+	}
+	if elem.MatchesTemplate(Must(`<a><c/><c/></a>`)) == true {
+		t.Fatal(`missing second <c> must not match`)
+	}
+	if elem.MatchesTemplate(Must(`<z/>`)) == true {
+		t.Fatal(`different name must not match`)
+	}
+
+	elem = nil
+	if elem.MatchesTemplate(Must(`<a/>`)) == true {
+		t.Fatal(`nil must not match non-nil template`)
+	}
+	if elem.MatchesTemplate(nil) == false {
+		t.Fatal(`nil template must match anything`)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	elem := Must(`<a id="1" class="x"><b>text</b><!--comment--><c/></a>`)
+
+	if elem.Equal(Must(`<a class="x" id="1"><b>text</b><!--comment--><c/></a>`)) == false {
+		t.Fatal(`attribute order must not matter`)
+	}
+	if elem.EqualOrdered(Must(`<a class="x" id="1"><b>text</b><!--comment--><c/></a>`)) == true {
+		t.Fatal(`attribute order must matter for EqualOrdered`)
+	}
+	if elem.EqualOrdered(elem.Clone()) == false {
+		t.Fatal(`elem.EqualOrdered(elem.Clone()) == false`)
+	}
+	if elem.Equal(Must(`<a id="1" class="x"><b>text</b><c/></a>`)) == true {
+		t.Fatal(`missing comment must not be equal`)
+	}
+	if elem.Equal(Must(`<a id="1" class="x"><b>text</b><!--other--><c/></a>`)) == true {
+		t.Fatal(`different comment must not be equal`)
+	}
+	if elem.Equal(Must(`<a id="1" class="x"><c/><!--comment--><b>text</b></a>`)) == true {
+		t.Fatal(`children order must matter`)
+	}
+	if elem.Equal(Must(`<a id="1" class="y"><b>text</b><!--comment--><c/></a>`)) == true {
+		t.Fatal(`different attribute value must not be equal`)
+	}
+	if Must(`<a><![CDATA[x]]></a>`).Equal(Must(`<a>x</a>`)) == false {
+		t.Fatal(`CDATA and text with the same content must be equal`)
+	}
+
+	var null *Element
+	if null.Equal(nil) == false {
+		t.Fatal(`nil.Equal(nil) == false`)
+	}
+	if null.Equal(elem) == true || elem.Equal(nil) == true {
+		t.Fatal(`nil and non-nil must not be equal`)
+	}
+}
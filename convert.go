@@ -0,0 +1,107 @@
+package dom
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"sort"
+)
+
+var (
+	// ErrNotPointer is returned when a value to be decoded into is not a non-nil pointer.
+	ErrNotPointer = errors.New("Not a non-nil pointer")
+)
+
+// ToMap converts the config-style element like <config><host>x</host><port>8080</port></config> into
+// a map from Name.Local of the child elements to their texts, i.e. {"host": "x", "port": "8080"}.
+// The text of a child is its DirectText, so an empty child like <empty/> is mapped to an empty string.
+//
+// It returns nil and false if elem cannot be mapped cleanly, i.e. if any child element has attributes
+// or child elements, elem has a text child, or two or more child elements have the same Name.Local,
+// since a map cannot hold duplicate keys. Comments and directives are ignored.
+func (elem *Element) ToMap() (map[string]string, bool) {
+	if elem == nil {
+		return nil, false
+	}
+
+	res := make(map[string]string, len(elem.Children))
+	for _, child := range elem.Children {
+		if _, ok := isText(child); ok == true {
+			return nil, false
+		}
+
+		childElem, ok := child.(*Element)
+		if ok == false {
+			continue
+		}
+
+		if len(childElem.Attr) > 0 || childElem.CountChildren() > 0 {
+			return nil, false
+		}
+
+		if _, ok := res[childElem.Name.Local]; ok == true {
+			return nil, false
+		}
+		res[childElem.Name.Local] = childElem.DirectText()
+	}
+	return res, true
+}
+
+// FromMap builds an element named root with a child element per key of m, whose Name.Local is the key
+// and whose text is the value, e.g. <config><host>x</host></config> for {"host": "x"}. The children of
+// an empty value have no text like <host></host>. Since the iteration order of maps is not specified,
+// the order of the children is not either. Use FromMapSorted for stable output.
+func FromMap(root string, m map[string]string) *Element {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return fromMap(root, keys, m)
+}
+
+// FromMapSorted works like FromMap, but the children are sorted by their names.
+func FromMapSorted(root string, m map[string]string) *Element {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fromMap(root, keys, m)
+}
+
+func fromMap(root string, keys []string, m map[string]string) *Element {
+	res := &Element{Name: xml.Name{Local: root}}
+	for _, key := range keys {
+		child := &Element{Name: xml.Name{Local: key}}
+		if value := m[key]; len(value) > 0 {
+			child.Children = []Node{xml.CharData(value)}
+		}
+		res.AppendChild(child)
+	}
+	return res
+}
+
+// Into decodes elem into v in the same manner as xml.Unmarshal, which lets the struct tags of v map
+// the loosely typed tree. elem is marshaled internally and the output is decoded by encoding/xml.
+// It returns ErrNotPointer if v is not a non-nil pointer.
+func (elem *Element) Into(v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr || rv.IsNil() == true {
+		return ErrNotPointer
+	}
+
+	data, err := elem.Marshal(true, true)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal([]byte(data), v)
+}
+
+// FromStruct builds an element from v, which may be a struct or a pointer to it, in the same manner as
+// xml.Marshal. It is the counterpart of Into. v is marshaled by encoding/xml and the output is parsed.
+func FromStruct(v interface{}) (*Element, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
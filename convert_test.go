@@ -0,0 +1,103 @@
+package dom
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestToMap(t *testing.T) {
+	elem := Must(`<config><host>example.com</host><!--comment--><port>8080</port><empty/></config>`)
+	res, ok := elem.ToMap()
+	if ok == false || len(res) != 3 || res["host"] != "example.com" || res["port"] != "8080" {
+		t.Fatal(res)
+	}
+	if value, ok := res["empty"]; ok == false || len(value) > 0 {
+		t.Fatal(`<empty/> must be mapped to an empty string`)
+	}
+
+	if res, ok := Must(`<config/>`).ToMap(); ok == false || len(res) != 0 {
+		t.Fatal(res)
+	}
+
+	for _, input := range []string{
+		`<config><host name="x"/></config>`,
+		`<config><server><host>x</host></server></config>`,
+		`<config><port>80</port><port>8080</port></config>`,
+		`<config>text<host>x</host></config>`,
+	} {
+		if res, ok := Must(input).ToMap(); ok == true || res != nil {
+			t.Fatal(input)
+		}
+	}
+
+	elem = nil
+	if res, ok := elem.ToMap(); ok == true || res != nil {
+		t.Fatal(res)
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	m := map[string]string{"port": "8080", "host": "example.com", "empty": ""}
+
+	elem := FromMapSorted("config", m)
+	if res, _ := elem.Marshal(false, false); res != `<config><empty></empty><host>example.com</host><port>8080</port></config>` {
+		t.Fatal(res)
+	}
+	if elem.FindChild("host").Parent != elem || elem.FindChild("empty").Children != nil {
+		t.Fatal(`the children must be attached to the root`)
+	}
+
+	elem = FromMap("config", m)
+	if res, ok := elem.ToMap(); ok == false || len(res) != 3 || res["host"] != "example.com" || res["empty"] != "" {
+		t.Fatal(res)
+	}
+
+	for _, elem := range []*Element{FromMap("config", nil), FromMapSorted("config", map[string]string{})} {
+		if res, _ := elem.Marshal(false, false); res != `<config></config>` {
+			t.Fatal(res)
+		}
+	}
+}
+
+type convertServer struct {
+	XMLName xml.Name `xml:"server"`
+	Name    string   `xml:"name,attr"`
+	Host    string   `xml:"host"`
+	Ports   []int    `xml:"port"`
+}
+
+func TestInto(t *testing.T) {
+	elem := Must(`<server name="web"><host>example.com</host><port>80</port><port>443</port><extra/></server>`)
+
+	var res convertServer
+	if err := elem.Into(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Name != "web" || res.Host != "example.com" || len(res.Ports) != 2 || res.Ports[1] != 443 {
+		t.Fatal(res)
+	}
+
+	var nilServer *convertServer
+	for _, v := range []interface{}{res, nilServer, nil} {
+		if err := elem.Into(v); err != ErrNotPointer {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFromStruct(t *testing.T) {
+	v := convertServer{Name: "web", Host: "a<b", Ports: []int{80}}
+	for _, v := range []interface{}{v, &v} {
+		elem, err := FromStruct(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res, _ := elem.Marshal(false, false); res != `<server name="web"><host>a&lt;b</host><port>80</port></server>` {
+			t.Fatal(res)
+		}
+	}
+
+	if _, err := FromStruct(make(chan int)); err == nil {
+		t.Fatal(`unsupported types must fail`)
+	}
+}
@@ -0,0 +1,69 @@
+package dom
+
+import (
+	"strconv"
+	"strings"
+)
+
+// String returns a compact single-line representation of elem for logs and error messages, like
+// `<a id="1">[2 children]</a>` for an element with two children of any type, or `<a id="1"/>` for one without
+// children. Only Name.Local of the names is written and the children are not visited, so it is cheap, but it is
+// not XML; use Marshal for that. It returns "<nil>" if elem is nil.
+func (elem *Element) String() string {
+	if elem == nil {
+		return "<nil>"
+	}
+
+	var b strings.Builder
+	b.WriteByte('<')
+	b.WriteString(elem.Name.Local)
+	for _, attr := range elem.Attr {
+		b.WriteByte(' ')
+		b.WriteString(attr.Name.Local)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(attr.Value))
+	}
+
+	switch n := len(elem.Children); n {
+	case 0:
+		b.WriteString("/>")
+		return b.String()
+	case 1:
+		b.WriteString(">[1 child]")
+	default:
+		b.WriteString(">[" + strconv.Itoa(n) + " children]")
+	}
+	b.WriteString("</" + elem.Name.Local + ">")
+	return b.String()
+}
+
+// Outline returns an indented outline of the element names in the subtree of elem, one element
+// per line, without attributes or any other nodes. Each level is indented by two spaces.
+// Elements deeper than maxDepth levels below elem are omitted and the place where they are omitted
+// is marked with "...". A negative maxDepth means unlimited.
+func (elem *Element) Outline(maxDepth int) string {
+	if elem == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	elem.outline(&b, 0, maxDepth)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (elem *Element) outline(b *strings.Builder, depth, maxDepth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(elem.Name.Local)
+	b.WriteByte('\n')
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			if depth == maxDepth {
+				b.WriteString(strings.Repeat("  ", depth+1))
+				b.WriteString("...\n")
+				return
+			}
+			childElem.outline(b, depth+1, maxDepth)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package dom
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOutline(t *testing.T) {
+	elem := Must(`<a x="1">text<b><c/><d>text</d></b><!--comment--><e/></a>`)
+
+	expected := `a
+  b
+    c
+    d
+  e`
+	if res := elem.Outline(-1); res != expected {
+		t.Fatal(res)
+	}
+
+	expected = `a
+  b
+    ...
+  e`
+	if res := elem.Outline(1); res != expected {
+		t.Fatal(res)
+	}
+
+	if res := elem.Outline(0); res != "a\n  ..." {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res := elem.Outline(-1); len(res) != 0 {
+		t.Fatal(res)
+	}
+}
+
+func TestString(t *testing.T) {
+	elem := Must(`<a id="1" title="say &quot;hi&quot;"><b/>text</a>`)
+	if res := elem.String(); res != `<a id="1" title="say \"hi\"">[2 children]</a>` {
+		t.Fatal(res)
+	}
+	if res := fmt.Sprint(elem.FindChild("b")); res != `<b/>` {
+		t.Fatal(res)
+	}
+	if res := fmt.Sprintf("%v", Must(`<a>text</a>`)); res != `<a>[1 child]</a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res := fmt.Sprintf("%s", elem); res != `<nil>` {
+		t.Fatal(res)
+	}
+}
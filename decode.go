@@ -0,0 +1,273 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// DecodeOptions holds the options to customize the decoding by UnmarshalWith.
+// The zero value decodes in the same manner as xml.Unmarshal with UnmarshalXML.
+type DecodeOptions struct {
+	// PreserveWhitespace keeps texts as they are. By default, leading and trailing whitespaces of
+	// texts are trimmed and the texts which become empty are discarded.
+	PreserveWhitespace bool
+
+	// Entity maps the names of the entities other than the predefined ones to their replacement texts,
+	// e.g. xml.HTMLEntity for the HTML entities like "&nbsp;". It works like xml.Decoder.Entity, and
+	// the references to the entities not in the map are syntax errors.
+	Entity map[string]string
+
+	// MaxDepth, when positive, limits the nesting depth of elements, where the root element is at depth 1.
+	// The decoding fails with ErrMaxDepth as soon as an element exceeds the limit. 0 means unlimited.
+	MaxDepth int
+
+	// MaxNodes, when positive, limits the number of the decoded nodes including the root element.
+	// The decoding fails with ErrMaxNodes as soon as the node exceeding the limit is decoded. 0 means unlimited.
+	MaxNodes int
+
+	// MaxBytes, when positive, limits the number of bytes read from the input. The decoding fails with
+	// ErrInputTooLarge as soon as it needs to read beyond the limit. 0 means unlimited.
+	MaxBytes int64
+
+	// RejectDuplicateAttr makes the decoding fail with ErrDuplicateAttr when an element has two or more
+	// attributes with the same Name, i.e. the same Name.Space and Name.Local, which is a well-formedness
+	// error in XML. By default such attributes are all kept in Attr for compatibility.
+	RejectDuplicateAttr bool
+
+	// ChildrenCap, when positive, is the initial capacity of Children allocated when the first child of an
+	// element is decoded, which saves the reallocations while appending the following children of wide
+	// elements at the cost of the unused capacity of narrow ones. 0 leaves the growth to append.
+	// The elements without children keep Children nil.
+	ChildrenCap int
+
+	// Pool, when set, provides the decoded elements and their Children slices. See ParserPool.
+	Pool *ParserPool
+}
+
+var (
+	// ErrMaxDepth is returned when the nesting of elements exceeds DecodeOptions.MaxDepth.
+	ErrMaxDepth = errors.New("Maximum depth exceeded")
+
+	// ErrMaxNodes is returned when the number of nodes exceeds DecodeOptions.MaxNodes.
+	ErrMaxNodes = errors.New("Maximum number of nodes exceeded")
+
+	// ErrDuplicateAttr is returned when an element has duplicate attributes with DecodeOptions.RejectDuplicateAttr.
+	ErrDuplicateAttr = errors.New("Duplicate attribute")
+)
+
+// UnmarshalWith works like xml.Unmarshal(data, elem), but it decodes according to opts.
+// Unlike xml.Unmarshal, CDATA sections are decoded as CData nodes.
+func UnmarshalWith(data []byte, elem *Element, opts DecodeOptions) error {
+	return newReaderDecoder(bytes.NewReader(data), &opts).decodeRoot(elem)
+}
+
+// decoder decodes Element trees from xml.Decoder according to DecodeOptions.
+type decoder struct {
+	*xml.Decoder
+	opts *DecodeOptions
+
+	// raw records the input when the decoder reads it by itself, which is used to tell CDATA sections
+	// from the other texts since xml.Decoder reports both as xml.CharData. It is nil otherwise.
+	raw *recorder
+
+	// offset is the input offset where the last token returned by token starts.
+	offset int64
+
+	// depth is the depth of the element being decoded.
+	depth int
+
+	// nodes is the number of the decoded nodes.
+	nodes int
+}
+
+func newDecoder(d *xml.Decoder, opts *DecodeOptions) *decoder {
+	return &decoder{Decoder: d, opts: opts}
+}
+
+func newReaderDecoder(r io.Reader, opts *DecodeOptions) *decoder {
+	if opts.MaxBytes > 0 {
+		r = &limitedReader{r: r, n: opts.MaxBytes}
+	}
+
+	raw := &recorder{r: r}
+	d := newDecoder(xml.NewDecoder(raw), opts)
+	d.raw = raw
+	d.Entity = opts.Entity
+	return d
+}
+
+// token works like Token, but it remembers where the token starts.
+func (d *decoder) token() (xml.Token, error) {
+	d.offset = d.InputOffset()
+	if d.raw != nil {
+		d.raw.discard(d.offset)
+	}
+	return d.Token()
+}
+
+// decodeRoot skips the tokens preceding the root element and decodes it into elem.
+func (d *decoder) decodeRoot(elem *Element) error {
+	for {
+		next, err := d.token()
+		if err != nil {
+			return err
+		}
+
+		if start, ok := next.(xml.StartElement); ok == true {
+			return d.decodeElement(elem, start)
+		}
+	}
+}
+
+// decodeElement decodes the content of elem following start until the matching end element.
+func (d *decoder) decodeElement(elem *Element, start xml.StartElement) (err error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.opts.MaxDepth > 0 && d.depth > d.opts.MaxDepth {
+		return ErrMaxDepth
+	}
+
+	if err = d.countNode(); err != nil {
+		return
+	}
+
+	if d.opts.RejectDuplicateAttr == true && duplicateAttr(start.Attr) >= 0 {
+		return ErrDuplicateAttr
+	}
+
+	copy := start.Copy()
+	elem.Name = copy.Name
+	elem.Attr = copy.Attr
+	if d.opts.Pool != nil {
+		elem.Children = elem.Children[:0]
+	} else {
+		elem.Children = nil
+	}
+
+	for {
+		var next xml.Token
+		if next, err = d.token(); err != nil {
+			return
+		}
+
+		if _, ok := next.(xml.EndElement); ok == true {
+			return
+		}
+
+		var node Node
+		if node, err = d.decodeNode(next); err != nil {
+			return
+		}
+
+		if node != nil {
+			if elem.Children == nil && d.opts.ChildrenCap > 0 {
+				elem.Children = make([]Node, 0, d.opts.ChildrenCap)
+			}
+			setParent(node, elem)
+			elem.Children = append(elem.Children, node)
+		}
+	}
+}
+
+// duplicateAttrThreshold is the number of attributes above which duplicateAttr uses a map
+// instead of comparing the pairs, so that a start tag with many attributes costs O(n).
+const duplicateAttrThreshold = 8
+
+// duplicateAttr returns the index of the first attribute in attrs having the same Name as a preceding one,
+// or -1 if there is none.
+func duplicateAttr(attrs []xml.Attr) int {
+	if len(attrs) <= duplicateAttrThreshold {
+		for i := range attrs {
+			for _, prev := range attrs[:i] {
+				if prev.Name == attrs[i].Name {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	seen := make(map[xml.Name]struct{}, len(attrs))
+	for i, attr := range attrs {
+		if _, ok := seen[attr.Name]; ok == true {
+			return i
+		}
+		seen[attr.Name] = struct{}{}
+	}
+	return -1
+}
+
+// decodeNode converts token into a Node, decoding the whole element if token is xml.StartElement.
+// It returns nil if token is to be ignored.
+func (d *decoder) decodeNode(next xml.Token) (Node, error) {
+	switch token := next.(type) {
+	case xml.CharData:
+		if d.raw != nil && d.raw.hasPrefix(d.offset, "<![CDATA[") == true {
+			return CData(token), d.countNode()
+		}
+		if d.opts.PreserveWhitespace == true {
+			return xml.CopyToken(token), d.countNode()
+		}
+		// Ignore whitespaces
+		if text := strings.TrimSpace(string(token)); len(text) > 0 {
+			return xml.CharData(text), d.countNode()
+		}
+	case xml.Comment, xml.Directive:
+		return xml.CopyToken(token), d.countNode()
+	case xml.StartElement:
+		child := d.opts.Pool.Get()
+		if err := d.decodeElement(child, token); err != nil {
+			return nil, err
+		}
+		return child, nil
+	}
+	return nil, nil
+}
+
+// countNode counts a decoded node and returns ErrMaxNodes if it exceeds the limit.
+func (d *decoder) countNode() error {
+	d.nodes++
+	if d.opts.MaxNodes > 0 && d.nodes > d.opts.MaxNodes {
+		return ErrMaxNodes
+	}
+	return nil
+}
+
+// recorder is an io.Reader which keeps the data read from r since the last discard.
+// The discarded data is only skipped by start, and it is dropped on Read once it occupies
+// half of buf, so that discarding costs O(1) amortized.
+type recorder struct {
+	r     io.Reader
+	buf   []byte
+	start int   // the index of the first byte kept in buf
+	base  int64 // the input offset of buf[start]
+}
+
+func (r *recorder) Read(p []byte) (n int, err error) {
+	if r.start > 0 && r.start >= len(r.buf)/2 {
+		r.buf = r.buf[:copy(r.buf, r.buf[r.start:])]
+		r.start = 0
+	}
+
+	n, err = r.r.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	return
+}
+
+// hasPrefix reports whether the input at offset starts with prefix.
+func (r *recorder) hasPrefix(offset int64, prefix string) bool {
+	kept := r.buf[r.start:]
+	i := offset - r.base
+	return i >= 0 && i <= int64(len(kept)) && bytes.HasPrefix(kept[i:], []byte(prefix))
+}
+
+// discard drops the data preceding offset.
+func (r *recorder) discard(offset int64) {
+	if i := offset - r.base; i > 0 && i <= int64(len(r.buf)-r.start) {
+		r.start += int(i)
+		r.base = offset
+	}
+}
@@ -0,0 +1,194 @@
+package dom
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalWithPreserveWhitespace(t *testing.T) {
+	input := `<a>  <b/>  <code>  indented</code></a>`
+
+	elem := &Element{}
+	if err := UnmarshalWith([]byte(input), elem, DecodeOptions{PreserveWhitespace: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(elem.Children) != 4 {
+		t.Fatal(elem.Children)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a>  <b></b>  <code>  indented</code></a>` {
+		t.Fatal(res)
+	}
+
+	elem = &Element{}
+	if err := UnmarshalWith([]byte(input), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a><b></b><code>indented</code></a>` {
+		t.Fatal(res)
+	}
+
+	if err := UnmarshalWith([]byte(`<a><b></a>`), &Element{}, DecodeOptions{}); err == nil {
+		t.Fatal(`UnmarshalWith() must fail with malformed input`)
+	}
+}
+
+func TestCData(t *testing.T) {
+	input := `<script><![CDATA[if (a < b && c) { x = "&amp;"; }]]><b><![CDATA[ <kept> ]]></b>text</script>`
+
+	elem := &Element{}
+	if err := UnmarshalWith([]byte(input), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if cdata, ok := elem.Children[0].(CData); ok == false || cdata != `if (a < b && c) { x = "&amp;"; }` {
+		t.Fatal(elem.Children[0])
+	}
+	if text, _ := elem.Children[1].(*Element).Text(); text != " <kept> " {
+		t.Fatal(text)
+	}
+
+	res, err := elem.Marshal(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != input {
+		t.Fatal(res)
+	}
+
+	// The references in CDATA sections are not touched by the replacements of the options
+	input = `<a><![CDATA[&#39;&#34;&#xA;]]&gt;]]></a>`
+	if err = UnmarshalWith([]byte(input), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ = elem.Marshal(false, false); res != input {
+		t.Fatal(res)
+	}
+	if res, _ = elem.MarshalOpts(MarshalOptions{UnescapeGT: true, UnescapeNewlineInAttr: true}); res != input {
+		t.Fatal(res)
+	}
+
+	// CData falls back to an escaped text if it contains "]]>" or characters greater than MaxRune
+	elem = &Element{Name: xml.Name{Local: "a"}, Children: []Node{CData("x]]>y")}}
+	if res, _ = elem.Marshal(false, false); res != `<a>x]]&gt;y</a>` {
+		t.Fatal(res)
+	}
+	if err = UnmarshalWith([]byte(res), elem, DecodeOptions{}); err != nil || elem.TextRecurse() != "x]]>y" {
+		t.Fatal(elem.TextRecurse())
+	}
+	if res, _ = (&Element{Name: xml.Name{Local: "a"}, Children: []Node{CData("café")}}).MarshalOpts(MarshalOptions{MaxRune: 0x7F}); res != `<a>caf&#x00E9;</a>` {
+		t.Fatal(res)
+	}
+
+	// xml.Marshal falls back to an escaped text
+	b, _ := xml.Marshal(elem)
+	if string(b) != `<a>x]]&gt;y</a>` {
+		t.Fatal(string(b))
+	}
+}
+
+func TestUnmarshalWithEntity(t *testing.T) {
+	elem := &Element{}
+	opts := DecodeOptions{Entity: map[string]string{"nbsp": "\u00a0", "copy": "(c)", "company": "Example"}, PreserveWhitespace: true}
+	if err := UnmarshalWith([]byte(`<a x="&copy;">&nbsp;&amp;&company;</a>`), elem, opts); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := elem.Text(); text != "\u00a0&Example" || elem.FindAttr("x").Value != "(c)" {
+		t.Fatal(text)
+	}
+
+	if err := UnmarshalWith([]byte(`<a>&nbsp;</a>`), &Element{}, DecodeOptions{}); err == nil {
+		t.Fatal(`unknown entities must be errors`)
+	}
+
+	if err := UnmarshalWith([]byte(`<a>&eacute;&nbsp;</a>`), elem, DecodeOptions{Entity: xml.HTMLEntity, PreserveWhitespace: true}); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := elem.Text(); text != "\u00e9\u00a0" {
+		t.Fatal(text)
+	}
+}
+
+func TestDecodeChildrenCap(t *testing.T) {
+	const input = `<a><b/><c>text</c><d/></a>`
+
+	elem := &Element{}
+	if err := UnmarshalWith([]byte(input), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(elem.Children) != 3 || elem.FindChild("b").Children != nil {
+		t.Fatal(len(elem.Children))
+	}
+
+	for _, n := range []int{-1, 1, 16} {
+		res := &Element{}
+		if err := UnmarshalWith([]byte(input), res, DecodeOptions{ChildrenCap: n}); err != nil {
+			t.Fatal(err)
+		}
+		if res.EqualOrdered(elem) == false || n == 16 && cap(res.Children) != 16 {
+			t.Fatal(n)
+		}
+	}
+}
+
+// BenchmarkDecodeWide decodes a wide document with and without pre-sizing Children.
+// Compare the allocations with -benchmem.
+func BenchmarkDecodeWide(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("<root>")
+	for i := 0; i < 1000; i++ {
+		sb.WriteString(`<item id="x"><a>1</a><b>2</b><c>3</c></item>`)
+	}
+	sb.WriteString("</root>")
+	data := []byte(sb.String())
+
+	for _, bm := range []struct {
+		name string
+		cap  int
+	}{{"append", 0}, {"cap4", 4}, {"cap16", 16}} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := UnmarshalWith(data, &Element{}, DecodeOptions{ChildrenCap: bm.cap}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRejectDuplicateAttr(t *testing.T) {
+	input := []byte(`<a xmlns:p="urn:p" xmlns:q="urn:q"><b x="1" x="2"/></a>`)
+
+	elem := &Element{}
+	if err := UnmarshalWith(input, elem, DecodeOptions{}); err != nil || len(elem.FindChild("b").Attr) != 2 {
+		t.Fatal(`duplicate attributes must be kept by default`)
+	}
+
+	opts := DecodeOptions{RejectDuplicateAttr: true}
+	if err := UnmarshalWith(input, &Element{}, opts); err != ErrDuplicateAttr {
+		t.Fatal(err)
+	}
+	if _, err := ParseWith([]byte(`<a xmlns:p="urn:p"><b p:x="1" x="2" xmlns:r="urn:p" r:x="3"/></a>`), opts); errors.Is(err, ErrDuplicateAttr) == false {
+		t.Fatal(err)
+	}
+
+	// The attributes with the same local name in different namespaces are not duplicates.
+	if err := UnmarshalWith([]byte(`<a xmlns:p="urn:p" xmlns:q="urn:q" p:x="1" q:x="2" x="3"/>`), &Element{}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Many attributes are checked with a map
+	var sb strings.Builder
+	sb.WriteString("<a")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, ` x%d="%d"`, i, i)
+	}
+	if err := UnmarshalWith([]byte(sb.String()+"/>"), &Element{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnmarshalWith([]byte(sb.String()+` x50="dup"/>`), &Element{}, opts); err != ErrDuplicateAttr {
+		t.Fatal(err)
+	}
+}
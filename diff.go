@@ -0,0 +1,110 @@
+package dom
+
+import "strconv"
+
+// DiffKind is the kind of a Difference.
+type DiffKind int
+
+const (
+	// NameMismatch means the elements have different names.
+	NameMismatch DiffKind = iota
+
+	// AttrMismatch means an attribute is missing in either element or has different values.
+	AttrMismatch
+
+	// TextMismatch means the direct text content of the elements differs.
+	TextMismatch
+
+	// ChildCountMismatch means the elements have different numbers of child elements.
+	ChildCountMismatch
+)
+
+func (kind DiffKind) String() string {
+	switch kind {
+	case NameMismatch:
+		return "NameMismatch"
+	case AttrMismatch:
+		return "AttrMismatch"
+	case TextMismatch:
+		return "TextMismatch"
+	case ChildCountMismatch:
+		return "ChildCountMismatch"
+	}
+	return "DiffKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// Difference describes a structural difference found by Diff.
+type Difference struct {
+	// Path locates the difference like "/a/b[1]/@id" or "/a/b[2]/text()", where the index is
+	// the 1-based position among the sibling elements with the same name.
+	Path string
+
+	Kind DiffKind
+
+	// A and B are the conflicting values, i.e. the names, the attribute values, the texts or the
+	// numbers of child elements. A missing attribute is represented by an empty string.
+	A, B string
+}
+
+func (d Difference) String() string {
+	return d.Path + ": " + d.Kind.String() + " " + strconv.Quote(d.A) + " != " + strconv.Quote(d.B)
+}
+
+// Diff compares a and b recursively and returns the differences in document order.
+// It returns nil if a and b are structurally equal.
+//
+// The attributes are compared as a set by Name.Local. The text of an element is DirectText. The child elements are compared pairwise in order,
+// and the surplus ones are reported only by a ChildCountMismatch. Comments and directives are ignored.
+// Nothing below the elements with different names is compared.
+func Diff(a, b *Element) (res []Difference) {
+	if a == nil || b == nil {
+		if a != b {
+			res = append(res, Difference{Path: "/", Kind: NameMismatch, A: diffName(a), B: diffName(b)})
+		}
+		return
+	}
+
+	return diff(res, "/"+a.Name.Local, a, b)
+}
+
+func diffName(elem *Element) string {
+	if elem == nil {
+		return ""
+	}
+	return elem.Name.Local
+}
+
+func diff(res []Difference, path string, a, b *Element) []Difference {
+	if a.Name != b.Name {
+		return append(res, Difference{Path: path, Kind: NameMismatch, A: a.Name.Local, B: b.Name.Local})
+	}
+
+	for _, attr := range a.Attr {
+		if value, _ := b.GetAttr(attr.Name.Local); b.HasAttr(attr.Name.Local) == false || value != attr.Value {
+			res = append(res, Difference{Path: path + "/@" + attr.Name.Local, Kind: AttrMismatch, A: attr.Value, B: value})
+		}
+	}
+	for _, attr := range b.Attr {
+		if a.HasAttr(attr.Name.Local) == false {
+			res = append(res, Difference{Path: path + "/@" + attr.Name.Local, Kind: AttrMismatch, B: attr.Value})
+		}
+	}
+
+	if textA, textB := a.DirectText(), b.DirectText(); textA != textB {
+		res = append(res, Difference{Path: path + "/text()", Kind: TextMismatch, A: textA, B: textB})
+	}
+
+	childrenA, childrenB := a.ChildElements(), b.ChildElements()
+	if len(childrenA) != len(childrenB) {
+		res = append(res, Difference{Path: path, Kind: ChildCountMismatch, A: strconv.Itoa(len(childrenA)), B: strconv.Itoa(len(childrenB))})
+	}
+
+	indices := map[string]int{}
+	for i := 0; i < len(childrenA) && i < len(childrenB); i++ {
+		name := childrenA[i].Name.Local
+		indices[name]++
+		res = diff(res, path+"/"+name+"["+strconv.Itoa(indices[name])+"]", childrenA[i], childrenB[i])
+	}
+
+	return res
+}
@@ -0,0 +1,37 @@
+package dom
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	a := Must(`<a><b id="1">x</b><b id="2" v="3"/><!--comment--><c><d/></c></a>`)
+
+	if res := Diff(a, a.Clone()); res != nil {
+		t.Fatal(res)
+	}
+
+	b := Must(`<a><b id="1">y</b><b id="9" w="4"/><c><e/><d/></c></a>`)
+	res := Diff(a, b)
+	want := []Difference{
+		{Path: "/a/b[1]/text()", Kind: TextMismatch, A: "x", B: "y"},
+		{Path: "/a/b[2]/@id", Kind: AttrMismatch, A: "2", B: "9"},
+		{Path: "/a/b[2]/@v", Kind: AttrMismatch, A: "3", B: ""},
+		{Path: "/a/b[2]/@w", Kind: AttrMismatch, A: "", B: "4"},
+		{Path: "/a/c[1]", Kind: ChildCountMismatch, A: "1", B: "2"},
+		{Path: "/a/c[1]/d[1]", Kind: NameMismatch, A: "d", B: "e"},
+	}
+	if len(res) != len(want) {
+		t.Fatal(res)
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Fatal(res[i].String())
+		}
+	}
+
+	if res := Diff(a, nil); len(res) != 1 || res[0].Kind != NameMismatch || res[0].A != "a" {
+		t.Fatal(res)
+	}
+	if res := Diff(nil, nil); res != nil {
+		t.Fatal(res)
+	}
+}
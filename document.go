@@ -0,0 +1,247 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document represents a whole XML document, i.e. the root element and the prolog preceding it.
+// The comments and processing instructions following the root element are not kept.
+type Document struct {
+	// Prolog holds xml.Comment, xml.Directive and xml.ProcInst nodes preceding Root in document order.
+	// The XML declaration is not included since it is written by the marshal functions on demand.
+	Prolog []Node
+
+	// Root is the root element of the document.
+	Root *Element
+}
+
+var (
+	// ErrNoRoot is returned when a document does not have the root element.
+	ErrNoRoot = errors.New("Root element not found")
+
+	regEntityDecl = regexp.MustCompile(`<!ENTITY\s+([^\s%]+)\s+(?:"([^"]*)"|'([^']*)')\s*>`)
+	regComment    = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// ParseDocument parses data as a whole XML document.
+//
+// Directives in the prolog such as <!DOCTYPE root [ ... ]> are kept with the exact bytes found in data,
+// including the internal DTD subset, and are written back verbatim by the marshal functions.
+// The internal general entities declared with literal values in the subset are resolved while parsing,
+// where the character references and the references to the predefined or preceding entities in the values
+// are expanded. The declarations whose values contain markup like '<' are ignored, so are the references
+// to them. Parsing stops at the end of the root element, and the following comments and processing
+// instructions are dropped.
+func ParseDocument(data []byte) (doc *Document, err error) {
+	doc = &Document{}
+	d := newReaderDecoder(bytes.NewReader(data), &DecodeOptions{})
+
+	for doc.Root == nil {
+		var next xml.Token
+		if next, err = d.token(); err != nil {
+			if err == io.EOF {
+				err = ErrNoRoot
+			}
+			return nil, err
+		}
+
+		switch token := next.(type) {
+		case xml.Comment:
+			doc.Prolog = append(doc.Prolog, xml.CopyToken(token))
+		case xml.ProcInst:
+			if token.Target != "xml" {
+				doc.Prolog = append(doc.Prolog, xml.CopyToken(token))
+			}
+		case xml.Directive:
+			raw := data[d.offset:d.InputOffset()]
+			raw = raw[2 : len(raw)-1] // strip "<!" and ">"
+			doc.Prolog = append(doc.Prolog, xml.Directive(append([]byte{}, raw...)))
+			d.Entity = appendEntities(d.Entity, raw)
+		case xml.StartElement:
+			root := &Element{}
+			if err = d.decodeElement(root, token); err != nil {
+				return nil, err
+			}
+			doc.Root = root
+		}
+	}
+
+	return
+}
+
+// appendEntities adds the internal general entities declared in directive to entities.
+// The declarations in the comments in directive are ignored.
+func appendEntities(entities map[string]string, directive []byte) map[string]string {
+	for _, m := range regEntityDecl.FindAllSubmatch(regComment.ReplaceAll(directive, nil), -1) {
+		value, ok := expandEntityValue(string(m[2])+string(m[3]), entities)
+		if ok == false {
+			continue
+		}
+
+		if entities == nil {
+			entities = map[string]string{}
+		}
+		entities[string(m[1])] = value
+	}
+	return entities
+}
+
+// expandEntityValue expands the character references and the references to the predefined entities
+// or entities in the literal value s of an entity declaration, since xml.Decoder inserts the values
+// as plain texts. It returns false if s contains markup or references which cannot be expanded.
+func expandEntityValue(s string, entities map[string]string) (string, bool) {
+	if strings.ContainsRune(s, '<') == true {
+		return "", false
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '&')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), true
+		}
+
+		j := strings.IndexByte(s[i:], ';')
+		if j < 0 {
+			return "", false
+		}
+
+		b.WriteString(s[:i])
+		name := s[i+1 : i+j]
+		s = s[i+j+1:]
+
+		if r, ok := charRef(name); ok == true {
+			b.WriteRune(r)
+		} else if value, ok := predefinedEntity[name]; ok == true {
+			b.WriteString(value)
+		} else if value, ok := entities[name]; ok == true {
+			b.WriteString(value)
+		} else {
+			return "", false
+		}
+	}
+}
+
+// predefinedEntity maps the names of the predefined entities to their replacement texts.
+var predefinedEntity = map[string]string{"lt": "<", "gt": ">", "amp": "&", "apos": "'", "quot": `"`}
+
+// charRef returns the character referred by name of a character reference like "#60" or "#x3C".
+func charRef(name string) (rune, bool) {
+	if strings.HasPrefix(name, "#") == false {
+		return 0, false
+	}
+
+	base, digits := 10, name[1:]
+	if strings.HasPrefix(digits, "x") == true {
+		base, digits = 16, digits[1:]
+	}
+
+	n, err := strconv.ParseUint(digits, base, 32)
+	if err != nil || utf8.ValidRune(rune(n)) == false {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// Marshal returns the XML encoding of doc. Each node of Prolog is written on its own line
+// followed by Root. See Element.Marshal for the details of the options.
+func (doc *Document) Marshal(escQuot, escApos bool) (string, error) {
+	if doc == nil {
+		return "", nil
+	}
+
+	root, err := doc.Root.Marshal(escQuot, escApos)
+	if err != nil {
+		return "", err
+	}
+
+	return doc.prolog() + root, nil
+}
+
+// MarshalIndent works like Marshal, but Root is indented in the same manner as Element.MarshalIndent.
+// The XML declaration precedes Prolog when withDecl is true, so the output is like:
+//
+//	<?xml version="1.0" encoding="utf-8"?>
+//	<!DOCTYPE html>
+//	<html>...</html>
+func (doc *Document) MarshalIndent(prefix, indent string, withDecl, escQuot, escApos bool) (string, error) {
+	if doc == nil {
+		return "", nil
+	}
+
+	root, err := doc.Root.MarshalIndent(prefix, indent, false, escQuot, escApos)
+	if err != nil {
+		return "", err
+	}
+
+	res := doc.prolog() + root
+	if withDecl == true {
+		res = xmlDecl + "\n" + res
+	}
+
+	return res, nil
+}
+
+// Directives returns the texts of the directives in Prolog in order, e.g. "DOCTYPE html" for <!DOCTYPE html>.
+func (doc *Document) Directives() (res []string) {
+	if doc == nil {
+		return
+	}
+
+	for _, node := range doc.Prolog {
+		if directive, ok := node.(xml.Directive); ok == true {
+			res = append(res, string(directive))
+		}
+	}
+	return
+}
+
+// SetDirective sets s, e.g. "DOCTYPE html", as the directive written above Root. It replaces the first
+// directive in Prolog, or appends s to Prolog if there is none. The XML declaration is not a directive
+// and is still controlled by the withDecl flag of the marshal functions.
+func (doc *Document) SetDirective(s string) {
+	if doc == nil {
+		return
+	}
+
+	for i, node := range doc.Prolog {
+		if _, ok := node.(xml.Directive); ok == true {
+			doc.Prolog[i] = xml.Directive(s)
+			return
+		}
+	}
+	doc.Prolog = append(doc.Prolog, xml.Directive(s))
+}
+
+func (doc *Document) prolog() string {
+	var b strings.Builder
+	for _, node := range doc.Prolog {
+		switch node := node.(type) {
+		case xml.Comment:
+			b.WriteString("<!--")
+			b.Write(node)
+			b.WriteString("-->\n")
+		case xml.Directive:
+			b.WriteString("<!")
+			b.Write(node)
+			b.WriteString(">\n")
+		case xml.ProcInst:
+			b.WriteString("<?")
+			b.WriteString(node.Target)
+			if len(node.Inst) > 0 {
+				b.WriteByte(' ')
+				b.Write(node.Inst)
+			}
+			b.WriteString("?>\n")
+		}
+	}
+	return b.String()
+}
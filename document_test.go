@@ -0,0 +1,111 @@
+package dom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocumentDirective(t *testing.T) {
+	input := `<?xml version="1.0" encoding="utf-8"?>
+<!-- banner -->
+<!DOCTYPE config [
+  <!ENTITY host "example.com">  <!-- kept -->
+  <!ENTITY port '8080'>
+]>
+<config>
+  <url>http://&host;:&port;/</url>
+</config>`
+
+	doc, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Prolog) != 2 {
+		t.Fatal(doc.Prolog)
+	}
+
+	text, _ := doc.Root.FindTextContaining("http")[0].Text()
+	if text != "http://example.com:8080/" {
+		t.Fatal(text)
+	}
+
+	res, err := doc.MarshalIndent("", "  ", true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<?xml version="1.0" encoding="utf-8"?>
+<!-- banner -->
+<!DOCTYPE config [
+  <!ENTITY host "example.com">  <!-- kept -->
+  <!ENTITY port '8080'>
+]>
+<config>
+  <url>http://example.com:8080/</url>
+</config>`
+	if res != expected {
+		t.Fatal(res)
+	}
+
+	if _, err = ParseDocument([]byte(`<!-- no root -->`)); err != ErrNoRoot {
+		t.Fatal(`ParseDocument() must fail without root element`)
+	}
+}
+
+func TestDocumentEntityValue(t *testing.T) {
+	input := `<!DOCTYPE a [
+  <!ENTITY co "AT&amp;T">
+  <!ENTITY lt2 "&#60;&#x3E;">
+  <!ENTITY full "&co; &lt2;">
+  <!-- <!ENTITY hidden "x"> -->
+  <!ENTITY markup "<b/>">
+]>
+<a>&co;|&lt2;|&full;</a>`
+
+	doc, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := doc.Root.Text(); text != "AT&T|<>|AT&T <>" {
+		t.Fatal(text)
+	}
+	if res, _ := doc.Root.Marshal(false, false); res != `<a>AT&amp;T|&lt;&gt;|AT&amp;T &lt;&gt;</a>` {
+		t.Fatal(res)
+	}
+
+	for _, ref := range []string{"&hidden;", "&markup;"} {
+		if _, err = ParseDocument([]byte(strings.Replace(input, "&co;", ref, 1))); err == nil {
+			t.Fatalf(`%s must not be declared`, ref)
+		}
+	}
+}
+
+func TestDocumentSetDirective(t *testing.T) {
+	doc := &Document{Root: Must(`<html><body/></html>`)}
+	doc.SetDirective("DOCTYPE html")
+
+	res, err := doc.MarshalIndent("", "", true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html><body /></html>` {
+		t.Fatal(res)
+	}
+
+	doc, err = ParseDocument([]byte(`<!--banner--><!DOCTYPE html><html/>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := doc.Directives(); len(res) != 1 || res[0] != "DOCTYPE html" {
+		t.Fatal(res)
+	}
+
+	doc.SetDirective(`DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "xhtml1-strict.dtd"`)
+	if res, _ = doc.Marshal(false, false); res != `<!--banner-->
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "xhtml1-strict.dtd">
+<html></html>` {
+		t.Fatal(res)
+	}
+}
@@ -1,36 +1,61 @@
 // Package dom privides the minimal interfaces to manipulate XML, which is developed on top of the standard xml package.
+//
+// Concurrency: an Element tree is not synchronized. The functions and methods which only read a tree,
+// such as the accessors, the search and traversal functions and the marshal functions, never modify it
+// nor keep any cache in it, so they are safe to be called from multiple goroutines on a tree which
+// no goroutine modifies. The ones which modify a tree, such as SetAttr, AppendChild, Normalize and
+// UnmarshalXML, must not be called concurrently with any other access to the same tree.
 package dom
 
 import (
 	"encoding/xml"
 	"errors"
 	"log"
-	"regexp"
 	"strings"
 )
 
 type (
-	// Node is an interface that holds Element, xml.Comment or xml.CharData
+	// Node is an interface that holds Element, xml.Comment, xml.CharData, xml.Directive or CData
 	Node interface{}
 
-	// Element represents an XML element
+	// CData represents a CDATA section, which is a text written as it is in <![CDATA[...]]>.
+	// It is produced by the package's own parse functions such as UnmarshalWith, while UnmarshalXML
+	// cannot tell CDATA sections from the other texts and produces xml.CharData for them.
+	CData string
+
+	// Element represents an XML element.
+	//
+	// Name.Space holds the namespace URI resolved by xml.Decoder, not the prefix written in the document.
+	// It is the prefix itself only if the prefix is not declared.
 	Element struct {
 		Name     xml.Name
 		Attr     []xml.Attr
 		Children []Node
+
+		// Parent is the parent element, or nil for the root element. It is only valid for trees produced by
+		// the package's own unmarshaling and mutation helpers such as AppendChild, so it becomes stale
+		// if Children is modified directly. It is never marshaled.
+		Parent *Element `xml:"-"`
 	}
 )
 
 var (
 	// ErrBreak ...
 	ErrBreak = errors.New("Break")
-
-	regSelfClosing = regexp.MustCompile(`></[^>]+>`)
 )
 
-// MarshalXML implements xml.Marshaler interface
+// MarshalXML implements xml.Marshaler interface.
+// Name.Space of elements and attributes is written with the prefixes declared by the xmlns attributes
+// in scope. A default namespace declaration is added to an element whose namespace has no prefix,
+// and a prefix like "ns1" is declared for an attribute whose namespace has no prefix.
 func (elem *Element) MarshalXML(e *xml.Encoder, start xml.StartElement) (err error) {
-	s := xml.StartElement{Name: elem.Name, Attr: elem.Attr}
+	return elem.marshalXML(e, &nsScope{})
+}
+
+func (elem *Element) marshalXML(e *xml.Encoder, ns *nsScope) (err error) {
+	s, mark := ns.push(elem)
+	defer ns.pop(mark)
+
 	if err = e.EncodeToken(s); err != nil {
 		return
 	}
@@ -38,59 +63,42 @@ func (elem *Element) MarshalXML(e *xml.Encoder, start xml.StartElement) (err err
 	for _, child := range elem.Children {
 		switch node := child.(type) {
 		case *Element:
-			if err = e.Encode(node); err != nil {
+			// A nil element is skipped in the same manner as xml.Encoder skips nil pointers.
+			if node == nil {
+				continue
+			}
+			if err = node.marshalXML(e, ns); err != nil {
 				return
 			}
 		case xml.CharData, xml.Comment, xml.Directive:
 			if err = e.EncodeToken(node); err != nil {
 				return
 			}
+		case CData:
+			// xml.Encoder cannot write CDATA sections, so it falls back to an escaped text.
+			if err = e.EncodeToken(xml.CharData(node)); err != nil {
+				return
+			}
 		}
 	}
 
-	if err = e.EncodeToken(xml.EndElement{Name: elem.Name}); err != nil {
+	if err = e.EncodeToken(s.End()); err != nil {
 		return
 	}
 
 	return
 }
 
-// UnmarshalXML implements xml.Unmarshaler interface
+// UnmarshalXML implements xml.Unmarshaler interface.
+// It resets elem first, i.e. Name, Attr and Children are replaced with the decoded ones.
+// Use DecodeAppend to add nodes to an existing element instead.
 func (elem *Element) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
-	copy := start.Copy()
-	elem.Name.Local = copy.Name.Local
-	elem.Attr = copy.Attr
-	var next xml.Token
-
-loop:
-	for {
-		switch next, err = d.Token(); token := next.(type) {
-		case xml.CharData:
-			// Ignore whitespaces
-			if text := strings.TrimSpace(string(token)); len(text) > 0 {
-				elem.Children = append(elem.Children, xml.CharData(text))
-			}
-		case xml.Comment, xml.Directive:
-			elem.Children = append(elem.Children, xml.CopyToken(token))
-		case xml.StartElement:
-			child := &Element{}
-			if err = d.DecodeElement(child, &token); err != nil {
-				break loop
-			}
-			elem.Children = append(elem.Children, child)
-		case xml.EndElement:
-			break loop
-		default:
-			if err != nil {
-				break loop
-			}
-		}
-	}
-	return
+	return newDecoder(d, &DecodeOptions{}).decodeElement(elem, start)
 }
 
 // Must is a helper that wraps xml.Unmarshal() and patics if the error is non-nil.
 // It is intended for use in variable initializations.
+// Since it terminates the process with log.Fatalf, use MustParse or Parse elsewhere.
 func Must(s string) *Element {
 	elem := &Element{}
 	if err := xml.Unmarshal([]byte(s), elem); err != nil {
@@ -104,11 +112,78 @@ func (elem *Element) IsEmpty() bool {
 	return elem == nil || len(elem.Attr) == 0 && len(elem.Children) == 0
 }
 
+// IsBlank returns true if elem has no Attr and its Children consist only of comments and
+// whitespace-only xml.CharData. Unlike IsEmpty, such children do not make elem non-blank.
+func (elem *Element) IsBlank() bool {
+	if elem == nil {
+		return true
+	}
+
+	if len(elem.Attr) > 0 {
+		return false
+	}
+
+	for _, child := range elem.Children {
+		switch node := child.(type) {
+		case xml.Comment:
+		case xml.CharData:
+			if len(strings.TrimSpace(string(node))) > 0 {
+				return false
+			}
+		case CData:
+			if len(strings.TrimSpace(string(node))) > 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 // HasAttr is a helper that is equivalent to elem.FindAttr(name) != nil. Do not overuse since it does linear search.
 func (elem *Element) HasAttr(name string) bool {
 	return elem.FindAttr(name) != nil
 }
 
+// HasAttrFold works like HasAttr, but Name.Local is compared with name case-insensitively.
+// Only ASCII letters are folded in the same manner as ForEachChildNamedFold.
+func (elem *Element) HasAttrFold(name string) bool {
+	if elem == nil {
+		return false
+	}
+
+	for _, attr := range elem.Attr {
+		if equalFoldASCII(attr.Name.Local, name) == true {
+			return true
+		}
+	}
+	return false
+}
+
+// equalFoldASCII reports whether s and t are equal under ASCII case folding. Unlike strings.EqualFold,
+// the non-ASCII characters must be identical.
+func equalFoldASCII(s, t string) bool {
+	if len(s) != len(t) {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		a, b := s[i], t[i]
+		if 'A' <= a && a <= 'Z' {
+			a += 'a' - 'A'
+		}
+		if 'A' <= b && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		if a != b {
+			return false
+		}
+	}
+	return true
+}
+
 // FindAttr finds attributes whose Name is name with linear search.
 func (elem *Element) FindAttr(name string) *xml.Attr {
 	if elem == nil {
@@ -126,33 +201,121 @@ func (elem *Element) FindAttr(name string) *xml.Attr {
 	return nil
 }
 
-// Text returns the plain text if the element has only one child whose type is xml.CharData.
+// GetAttr returns the Value of the attribute whose Name is name and true, or an empty string and false
+// if elem does not have such an attribute.
+func (elem *Element) GetAttr(name string) (string, bool) {
+	if attr := elem.FindAttr(name); attr != nil {
+		return attr.Value, true
+	}
+	return "", false
+}
+
+// Text returns the plain text if the element has only one child whose type is xml.CharData or CData.
 // Otherwise it returns an empty string and false.
 func (elem *Element) Text() (string, bool) {
 	if elem != nil && len(elem.Children) == 1 {
-		if charData, ok := elem.Children[0].(xml.CharData); ok == true {
-			return string(charData), true
+		switch text := elem.Children[0].(type) {
+		case xml.CharData:
+			return string(text), true
+		case CData:
+			return string(text), true
 		}
 	}
 	return "", false
 }
 
+// DirectText returns the concatenated texts of the xml.CharData and CData children of elem, ignoring
+// the child elements, e.g. "Hello  world" for <p>Hello <b>x</b> world</p>.
+//
+// The text functions differ as follows:
+//   - Text returns the text only when elem has exactly one text child and nothing else.
+//   - DirectText returns the texts of the children of elem, whatever other children it has.
+//   - TextRecurse returns the texts of all the descendants of elem, i.e. "Hello x world" for the above.
+func (elem *Element) DirectText() string {
+	if elem == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, child := range elem.Children {
+		if s, ok := isText(child); ok == true {
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
+
 // TextRecurse recursively traverses the DOM structure (children of the current Element),
-// and accumulates the text content found within xml.CharData instances.
+// and accumulates the text content found within xml.CharData and CData instances in document order.
+// Comments and directives are ignored. It returns an empty string if elem is nil.
 //
-// Returns a string which contains the accumulated text content from the Element
-// and all of its descendants in the tree structure.
-func (elem *Element) TextRecurse() (res string) {
+// Unlike Text, which only succeeds when elem has exactly one text child, TextRecurse always
+// returns the concatenated text content of elem and all of its descendants.
+func (elem *Element) TextRecurse() string {
+	if elem == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	elem.textRecurse(&b)
+	return b.String()
+}
+
+func (elem *Element) textRecurse(b *strings.Builder) {
 	for _, child := range elem.Children {
-		switch elem := child.(type) {
+		switch node := child.(type) {
 		case xml.CharData:
-			res += string(elem)
+			b.Write(node)
+		case CData:
+			b.WriteString(string(node))
 		case *Element:
-			res += elem.TextRecurse()
+			node.textRecurse(b)
 		}
 	}
+}
 
-	return
+// TextOptions holds the options of TextRecurseWith.
+type TextOptions struct {
+	// Sep is inserted between the text runs, i.e. the non-empty text nodes, but neither before the first one
+	// nor after the last one.
+	Sep string
+
+	// Comments and Directives include the texts of comments and directives as text runs respectively.
+	Comments, Directives bool
+}
+
+// TextRecurseSep works like TextRecurse, but sep is inserted between the text runs, e.g. "x, y, z" for
+// <a>x<b>y<c>z</c></b></a> with sep ", ". It is a shorthand for TextRecurseWith(TextOptions{Sep: sep}).
+func (elem *Element) TextRecurseSep(sep string) string {
+	return elem.TextRecurseWith(TextOptions{Sep: sep})
+}
+
+// TextRecurseWith works like TextRecurse, but the texts are accumulated according to opts.
+func (elem *Element) TextRecurseWith(opts TextOptions) string {
+	if elem == nil {
+		return ""
+	}
+
+	return strings.Join(elem.appendTextRuns(nil, &opts), opts.Sep)
+}
+
+func (elem *Element) appendTextRuns(runs []string, opts *TextOptions) []string {
+	for _, child := range elem.Children {
+		s, ok := isText(child)
+		switch node := child.(type) {
+		case *Element:
+			runs = node.appendTextRuns(runs, opts)
+		case xml.Comment:
+			s, ok = string(node), opts.Comments
+		case xml.Directive:
+			s, ok = string(node), opts.Directives
+		}
+
+		if ok == true && len(s) > 0 {
+			runs = append(runs, s)
+		}
+	}
+	return runs
 }
 
 // SetText clears all the existing children and append an xml.CharData node.
@@ -212,49 +375,38 @@ func (elem *Element) ForEachChildNamed(name string, fn func(child *Element) erro
 		})
 }
 
-// Marshal returns the XML encoding of elem.
-func (elem *Element) Marshal(escQuot, escApos bool) (res string, err error) {
-	dat, err := xml.Marshal(elem)
-	if err != nil {
-		return "", err
-	}
-
-	res = string(dat)
-
-	if escQuot == false {
-		res = strings.ReplaceAll(res, "&#34;", `"`)
-	}
+// ForEachChildNamedFold works like ForEachChildNamed, but Name.Local is compared with name case-insensitively
+// for HTML-ish documents. Only ASCII letters are folded, i.e. "TD" matches "td" but "É" does not match "é".
+func (elem *Element) ForEachChildNamedFold(name string, fn func(child *Element) error) (res *Element, err error) {
+	return elem.ForEachChildPred(
+		func(child *Element) bool {
+			return equalFoldASCII(child.Name.Local, name)
+		},
+		fn)
+}
 
-	if escApos == false {
-		res = strings.ReplaceAll(res, "&#39;", "'")
-	}
+// ForEachChildNS invokes fn on each child element whose Name.Space is space and Name.Local is local.
+// space is the namespace URI rather than the prefix, e.g. "http://www.w3.org/1999/xhtml" for <h:td>.
+// See also ForEachChild for the specifications of the return values.
+func (elem *Element) ForEachChildNS(space, local string, fn func(child *Element) error) (res *Element, err error) {
+	return elem.ForEachChildPred(
+		func(child *Element) bool {
+			return child.Name.Space == space && child.Name.Local == local
+		},
+		fn)
+}
 
-	return
+// Marshal returns the XML encoding of elem.
+// It is a shorthand for MarshalOpts with EscapeQuot and EscapeApos set to escQuot and escApos.
+func (elem *Element) Marshal(escQuot, escApos bool) (res string, err error) {
+	return elem.marshal(MarshalOptions{EscapeQuot: escQuot, EscapeApos: escApos})
 }
 
 // MarshalIndent works like Marshal, but XML element begins on a new indented line that starts
 // with prefix and is followed by one or more copies of indent according to the nesting depth.
+// Elements without any content are written as self-closing tags like "<name />".
+// It is a shorthand for MarshalOpts with SelfClosingEmpty and the corresponding fields of MarshalOptions.
 func (elem *Element) MarshalIndent(prefix, indent string, withDecl, escQuot, escApos bool) (res string, err error) {
-	dat, err := xml.MarshalIndent(elem, prefix, indent)
-	if err != nil {
-		return "", err
-	}
-
-	res = string(dat)
-
-	if escQuot == false {
-		res = strings.ReplaceAll(res, "&#34;", `"`)
-	}
-
-	if escApos == false {
-		res = strings.ReplaceAll(res, "&#39;", "'")
-	}
-
-	res = regSelfClosing.ReplaceAllString(res, " />")
-
-	if withDecl == true {
-		res = `<?xml version="1.0" encoding="utf-8"?>` + "\n" + res
-	}
-
-	return
+	opts := MarshalOptions{Prefix: prefix, Indent: indent, WithDecl: withDecl, EscapeQuot: escQuot, EscapeApos: escApos, SelfClosingEmpty: true}
+	return elem.marshal(opts)
 }
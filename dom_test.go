@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"log"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -81,6 +82,27 @@ func TestForEachChildNamed(t *testing.T) {
 	}
 }
 
+func TestForEachChildNS(t *testing.T) {
+	elem := Must(`<table xmlns:h="http://www.w3.org/1999/xhtml"><h:td>1</h:td><td>2</td><h:td>3</h:td></table>`)
+	var texts []string
+	elem.ForEachChildNS("http://www.w3.org/1999/xhtml", "td", func(child *Element) error {
+		text, _ := child.Text()
+		texts = append(texts, text)
+		return nil
+	})
+
+	if len(texts) != 2 || texts[0] != "1" || texts[1] != "3" {
+		t.Fatal(texts)
+	}
+
+	td, err := elem.ForEachChildNS("", "td", func(child *Element) error {
+		return ErrBreak
+	})
+	if err != nil || td == nil || td.TextRecurse() != "2" {
+		t.Fatal("ForEachChildNS with ErrBreak failed.")
+	}
+}
+
 func TestError(t *testing.T) {
 	elem := &Element{}
 	err := xml.Unmarshal([]byte(`<a><b/><c/><d/>text<e/>text<!--comment--><x><c/</x><c></c></a>`), elem)
@@ -157,6 +179,36 @@ func TestIsEmpty(t *testing.T) {
 	}
 }
 
+func TestIsBlank(t *testing.T) {
+	elem := &Element{Children: []Node{xml.CharData(" \n\t"), xml.Comment("comment")}}
+	if elem.IsBlank() == false {
+		t.Fatal("elem.IsBlank() == false")
+	}
+	if elem.IsEmpty() == true {
+		t.Fatal("elem.IsEmpty() == true")
+	}
+
+	elem.Children = append(elem.Children, xml.CharData("text"))
+	if elem.IsBlank() == true {
+		t.Fatal("elem.IsBlank() == true")
+	}
+
+	elem = Must(`<a><b/></a>`)
+	if elem.IsBlank() == true {
+		t.Fatal("elem.IsBlank() == true")
+	}
+
+	elem = Must(`<a b=""/>`)
+	if elem.IsBlank() == true {
+		t.Fatal("elem.IsBlank() == true")
+	}
+
+	elem = nil
+	if elem.IsBlank() == false {
+		t.Fatal("elem.IsBlank() == false")
+	}
+}
+
 func TestText(t *testing.T) {
 	elem := Must(`<a><s1/><s2></s2><s3>text</s3></a>`)
 	text, res := elem.Text()
@@ -222,6 +274,68 @@ func TestFindAttr(t *testing.T) {
 	}
 }
 
+func TestGetAttr(t *testing.T) {
+	elem := Must(`<a attr1="test1" attr2=""/>`)
+	if value, ok := elem.GetAttr("attr1"); ok == false || value != "test1" {
+		t.Fatal(`ok == false || value != "test1"`)
+	}
+	if value, ok := elem.GetAttr("attr2"); ok == false || len(value) > 0 {
+		t.Fatal(`ok == false || len(value) > 0`)
+	}
+	if value, ok := elem.GetAttr("attr3"); ok == true || len(value) > 0 {
+		t.Fatal(`ok == true || len(value) > 0`)
+	}
+	elem = nil
+	if value, ok := elem.GetAttr("attr1"); ok == true || len(value) > 0 {
+		t.Fatal(`ok == true || len(value) > 0`)
+	}
+}
+
+func TestTextRecurseSep(t *testing.T) {
+	elem := Must(`<a><b>x</b>y<!--comment--><c><d>z</d><e/></c><!DOCTYPE w></a>`)
+
+	if res := elem.TextRecurseSep(" "); res != "x y z" {
+		t.Fatal(res)
+	}
+	if res := elem.TextRecurseWith(TextOptions{Sep: "|", Comments: true}); res != "x|y|comment|z" {
+		t.Fatal(res)
+	}
+	if res := elem.TextRecurseWith(TextOptions{Sep: "|", Comments: true, Directives: true}); res != "x|y|comment|z|DOCTYPE w" {
+		t.Fatal(res)
+	}
+
+	if res := Must(`<a><b/></a>`).TextRecurseSep(","); res != "" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res := elem.TextRecurseSep(","); res != "" {
+		t.Fatal(res)
+	}
+}
+
+func TestDirectText(t *testing.T) {
+	elem := &Element{}
+	if err := UnmarshalWith([]byte(`<p>Hello <b>x</b> world<![CDATA[!]]><!--comment--></p>`), elem, DecodeOptions{PreserveWhitespace: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := elem.DirectText(); res != "Hello  world!" {
+		t.Fatal(res)
+	}
+	if _, ok := elem.Text(); ok == true {
+		t.Fatal(`elem.Text() must fail with mixed content`)
+	}
+	if res := elem.TextRecurse(); res != "Hello x world!" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res := elem.DirectText(); res != "" {
+		t.Fatal(res)
+	}
+}
+
 func TestTextRecurse(t *testing.T) {
 	input := `<PropertyGroup Condition="'$(CompileConfig)' == 'DEBUG'">
 	ThisIs
@@ -234,4 +348,86 @@ func TestTextRecurse(t *testing.T) {
 	if res != "ThisIsTestDeGonsuGonsu" {
 		t.Fatal(res)
 	}
+
+	elem = Must(`<a>x<!--comment--><b>y<c>z</c></b></a>`)
+	if res = elem.TextRecurse(); res != "xyz" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res = elem.TextRecurse(); len(res) != 0 {
+		t.Fatal(res)
+	}
+}
+
+// TestConcurrentRead is meant to be run with -race to verify that the read-only methods do not modify the tree.
+func TestConcurrentRead(t *testing.T) {
+	elem := Must(`<root xmlns:x="urn:x" id="r"><list><item id="1" x:v="a">one</item><item id="2"><![CDATA[two]]></item></list><!--comment--></root>`)
+	want, _ := elem.Marshal(false, false)
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if res, _ := elem.Marshal(false, false); res != want {
+					errs <- res
+					return
+				}
+				elem.MarshalIndent("", " ", true, false, false)
+				elem.CanonicalXML()
+				elem.MarshalJSON()
+				elem.XPath(`//item[@id='2']`)
+				elem.QuerySelectorAll(`list item`)
+				elem.GetElementByID("1")
+				elem.TextRecurse()
+				elem.AttrMap()
+				elem.Hash()
+				elem.ContentHash()
+				elem.Equal(elem)
+				Diff(elem, elem)
+				elem.Walk(func(e *Element) error { return nil }, nil)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for res := range errs {
+		t.Fatal(res)
+	}
+}
+
+func TestFold(t *testing.T) {
+	elem := Must(`<TABLE Border="1"><TR><TD/></TR><tr/><Tr/><ÉTÉ/></TABLE>`)
+
+	n := 0
+	res, err := elem.ForEachChildNamedFold("tr", func(child *Element) error {
+		n++
+		return nil
+	})
+	if res != nil || err != nil || n != 3 {
+		t.Fatal(n)
+	}
+	if res, _ := elem.ForEachChildNamed("tr", func(child *Element) error { return ErrBreak }); res != elem.Children[1] {
+		t.Fatal(`ForEachChildNamed must keep matching exactly`)
+	}
+
+	if res := elem.FindChildFold("tr"); res != elem.Children[0] || res.FindChildFold("td") == nil {
+		t.Fatal(res)
+	}
+	if elem.FindChildFold("été") != nil || elem.FindChildFold("ÉTÉ") == nil {
+		t.Fatal(`non-ASCII letters must not be folded`)
+	}
+
+	if elem.HasAttrFold("border") == false || elem.HasAttrFold("BORDER") == false || elem.HasAttr("border") == true {
+		t.Fatal(`HasAttrFold must ignore ASCII case`)
+	}
+
+	elem = nil
+	if elem.HasAttrFold("x") == true || elem.FindChildFold("x") != nil {
+		t.Fatal(`nil must not have attributes or children`)
+	}
 }
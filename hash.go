@@ -0,0 +1,115 @@
+package dom
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Hash returns the 64-bit FNV-1a hash of the subtree of elem. Every name, attribute and node
+// is included as it is, i.e. structurally identical trees have the same hash. Since each node is
+// hashed separately, the trees marshaled identically may still differ, e.g. when a text is split
+// into adjacent xml.CharData nodes. Apply Normalize to both trees beforehand to ignore such splits.
+func (elem *Element) Hash() uint64 {
+	h := fnv.New64a()
+	elem.hash(h, false)
+	return h.Sum64()
+}
+
+// ContentHash works like Hash, but it ignores the differences which do not change the content of
+// the document, so that documents only differing in formatting or comments hash identically:
+//   - Comments are excluded.
+//   - Attributes are hashed in the order of Name.Space and Name.Local regardless of the order in Attr.
+//   - CDATA sections are hashed as texts.
+//   - Consecutive texts are joined into one, skipping the comments between them. Then leading and
+//     trailing whitespaces of the text are trimmed and the other runs of whitespaces are collapsed
+//     into a single space. Texts which become empty are excluded.
+//
+// Names, attribute values, the order of child nodes and directives are included.
+func (elem *Element) ContentHash() uint64 {
+	h := fnv.New64a()
+	elem.hash(h, true)
+	return h.Sum64()
+}
+
+func (elem *Element) hash(h hash.Hash64, content bool) {
+	if elem == nil {
+		return
+	}
+
+	attrs := elem.Attr
+	if content == true {
+		attrs = append([]xml.Attr{}, attrs...)
+		sort.SliceStable(attrs, func(i, j int) bool {
+			if attrs[i].Name.Space != attrs[j].Name.Space {
+				return attrs[i].Name.Space < attrs[j].Name.Space
+			}
+			return attrs[i].Name.Local < attrs[j].Name.Local
+		})
+	}
+
+	hashString(h, 'E', elem.Name.Space)
+	hashString(h, 'N', elem.Name.Local)
+	for _, attr := range attrs {
+		hashString(h, 'A', attr.Name.Space)
+		hashString(h, 'N', attr.Name.Local)
+		hashString(h, 'V', attr.Value)
+	}
+
+	// In content mode, each run of texts is hashed as one text regardless of the comments between them.
+	var run strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(run.String()), " "); len(text) > 0 {
+			hashString(h, 'T', text)
+		}
+		run.Reset()
+	}
+
+	for _, child := range elem.Children {
+		switch node := child.(type) {
+		case *Element:
+			if content == true {
+				flush()
+			}
+			node.hash(h, content)
+		case xml.CharData, CData:
+			text, _ := isText(node)
+			if content == true {
+				run.WriteString(text)
+				continue
+			}
+			kind := byte('T')
+			if _, ok := node.(CData); ok == true {
+				kind = 'S'
+			}
+			hashString(h, kind, text)
+		case xml.Comment:
+			if content == false {
+				hashString(h, 'C', string(node))
+			}
+		case xml.Directive:
+			if content == true {
+				flush()
+			}
+			hashString(h, 'D', string(node))
+		}
+	}
+
+	if content == true {
+		flush()
+	}
+
+	hashString(h, '/', "")
+}
+
+// hashString writes a kind byte and a length-prefixed s, so that adjacent values never become ambiguous.
+func hashString(h hash.Hash64, kind byte, s string) {
+	var buf [binary.MaxVarintLen64 + 1]byte
+	buf[0] = kind
+	n := binary.PutUvarint(buf[1:], uint64(len(s)))
+	h.Write(buf[:n+1])
+	h.Write([]byte(s))
+}
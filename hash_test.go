@@ -0,0 +1,48 @@
+package dom
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	a := Must(`<config a="1" b="2"><!--comment--><name>foo  bar</name><v/></config>`)
+	b := Must(`<config b="2" a="1">
+  <name>
+    foo bar
+  </name>
+  <v/>
+</config>`)
+
+	if a.Hash() == b.Hash() {
+		t.Fatal(`a.Hash() == b.Hash()`)
+	}
+	if a.ContentHash() != b.ContentHash() {
+		t.Fatal(`a.ContentHash() != b.ContentHash()`)
+	}
+	if a.Hash() != Must(`<config a="1" b="2"><!--comment--><name>foo  bar</name><v></v></config>`).Hash() {
+		t.Fatal(`identical trees must have the same Hash()`)
+	}
+
+	c := &Element{Name: xml.Name{Local: "a"}, Children: []Node{xml.CharData("x"), xml.Comment("c"), xml.CharData("y"), CData(" z")}}
+	if Must(`<a>x<!--c-->y</a>`).ContentHash() != Must(`<a>xy</a>`).ContentHash() || c.ContentHash() != Must(`<a>xy z</a>`).ContentHash() {
+		t.Fatal(`texts separated by comments must be hashed as one text`)
+	}
+
+	for _, s := range []string{
+		`<config a="1" b="3"><name>foo bar</name><v/></config>`,
+		`<config a="1" b="2"><name>foobar</name><v/></config>`,
+		`<config a="1" b="2"><v/><name>foo bar</name></config>`,
+		`<config a="1" b="2"><name>foo bar</name><w/></config>`,
+		`<config a="1" b="2"><name>foo bar<v/></name></config>`,
+	} {
+		if Must(s).ContentHash() == a.ContentHash() {
+			t.Fatalf(`ContentHash() of %s must differ`, s)
+		}
+	}
+
+	var elem *Element
+	if elem.Hash() == a.Hash() || elem.ContentHash() == a.ContentHash() {
+		t.Fatal(`nil elem must have a different hash`)
+	}
+}
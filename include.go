@@ -0,0 +1,94 @@
+package dom
+
+import "errors"
+
+var (
+	// ErrIncludeCycle is returned when an included fragment includes itself directly or indirectly.
+	ErrIncludeCycle = errors.New("Recursive include")
+)
+
+// ResolveIncludes replaces each descendant element like <include href="..."/> with the root element
+// of the document loaded by loader(href). It is a shorthand for ResolveIncludesNamed("include", loader).
+func (elem *Element) ResolveIncludes(loader func(href string) ([]byte, error)) error {
+	return elem.ResolveIncludesNamed("include", loader)
+}
+
+// ResolveIncludesNamed replaces each descendant element whose Name.Local is name and which has
+// the href attribute with the root element of the document loaded by loader(href). The includes in
+// the loaded documents are resolved recursively, and ErrIncludeCycle is returned if a document includes
+// itself directly or indirectly. The elements without href are left as they are, and so is elem itself.
+//
+// loader is invoked with the value of href as it is, so it is responsible to resolve relative paths and
+// to restrict access if necessary. The errors from loader and Parse are directly returned, in which case
+// the includes resolved so far remain replaced.
+func (elem *Element) ResolveIncludesNamed(name string, loader func(href string) ([]byte, error)) error {
+	if elem == nil {
+		return nil
+	}
+
+	return elem.resolveIncludes(name, loader, nil)
+}
+
+// resolveIncludes resolves the includes in the descendants of elem, where stack holds the hrefs being loaded.
+func (elem *Element) resolveIncludes(name string, loader func(href string) ([]byte, error), stack []string) (err error) {
+	for i, child := range elem.Children {
+		childElem, ok := child.(*Element)
+		if ok == false {
+			continue
+		}
+
+		href, ok := includeHref(childElem, name)
+		if ok == false {
+			if err = childElem.resolveIncludes(name, loader, stack); err != nil {
+				return
+			}
+			continue
+		}
+
+		var loaded *Element
+		if loaded, err = loadInclude(href, name, loader, stack); err != nil {
+			return
+		}
+
+		childElem.Parent = nil
+		loaded.Parent = elem
+		elem.Children[i] = loaded
+	}
+	return
+}
+
+// loadInclude loads the document href and resolves the includes in it.
+func loadInclude(href, name string, loader func(href string) ([]byte, error), stack []string) (res *Element, err error) {
+	for _, loading := range stack {
+		if loading == href {
+			return nil, ErrIncludeCycle
+		}
+	}
+	stack = append(stack, href)
+
+	data, err := loader(href)
+	if err != nil {
+		return
+	}
+
+	if res, err = Parse(data); err != nil {
+		return
+	}
+
+	// The root element may be an include as well.
+	if rootHref, ok := includeHref(res, name); ok == true {
+		return loadInclude(rootHref, name, loader, stack)
+	}
+
+	if err = res.resolveIncludes(name, loader, stack); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func includeHref(elem *Element, name string) (string, bool) {
+	if elem.Name.Local != name {
+		return "", false
+	}
+	return elem.GetAttr("href")
+}
@@ -0,0 +1,48 @@
+package dom
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	files := map[string]string{
+		"db.xml":    `<database><host>localhost</host><include href="port.xml"/></database>`,
+		"port.xml":  `<port>5432</port>`,
+		"alias.xml": `<include href="port.xml"/>`,
+		"loop.xml":  `<loop><include href="loop2.xml"/></loop>`,
+		"loop2.xml": `<loop2><include href="loop.xml"/></loop2>`,
+	}
+	loader := func(href string) ([]byte, error) {
+		if s, ok := files[href]; ok == true {
+			return []byte(s), nil
+		}
+		return nil, errors.New("not found: " + href)
+	}
+
+	elem := Must(`<config><include href="db.xml"/><include/><x><include href="alias.xml"/></x></config>`)
+	if err := elem.ResolveIncludes(loader); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<config><database><host>localhost</host><port>5432</port></database><include></include><x><port>5432</port></x></config>` {
+		t.Fatal(res)
+	}
+	if db := elem.FindChild("database"); db.Parent != elem || db.FindChild("port").Parent != db {
+		t.Fatal(`Parent must be set`)
+	}
+
+	if err := Must(`<config><include href="loop.xml"/></config>`).ResolveIncludes(loader); err != ErrIncludeCycle {
+		t.Fatal(err)
+	}
+	if err := Must(`<config><include href="missing.xml"/></config>`).ResolveIncludes(loader); err == nil {
+		t.Fatal(`the error of loader must be returned`)
+	}
+
+	elem = Must(`<config><xi href="port.xml"/></config>`)
+	if err := elem.ResolveIncludesNamed("xi", loader); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<config><port>5432</port></config>` {
+		t.Fatal(res)
+	}
+}
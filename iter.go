@@ -0,0 +1,71 @@
+//go:build go1.23
+
+package dom
+
+import (
+	"encoding/xml"
+	"io"
+	"iter"
+)
+
+// Records returns an iterator over the elements whose Name.Local is name read from r.
+// The elements are matched at any depth, not only the direct children of the root element,
+// but the elements nested in a matched element are not reported separately since they are
+// decoded as a part of it. Only one record is held in memory at a time.
+//
+// A decoding error is reported once as the second value with a nil Element, and then the
+// iteration ends.
+func Records(r io.Reader, name string) iter.Seq2[*Element, error] {
+	return func(yield func(*Element, error) bool) {
+		d := newReaderDecoder(r, &DecodeOptions{})
+		for {
+			next, err := d.token()
+			if err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+
+			if start, ok := next.(xml.StartElement); ok == true && start.Name.Local == name {
+				elem := &Element{}
+				if err = d.decodeElement(elem, start); err != nil {
+					yield(nil, err)
+					return
+				}
+
+				if yield(elem, nil) == false {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Elements returns an iterator over the child elements in order, skipping texts, comments and directives.
+func (elem *Element) Elements() iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		if elem == nil {
+			return
+		}
+
+		for _, child := range elem.Children {
+			if childElem, ok := child.(*Element); ok == true && yield(childElem) == false {
+				return
+			}
+		}
+	}
+}
+
+// Descendants returns an iterator over the descendant elements in document order (preorder).
+// elem itself is not yielded.
+func (elem *Element) Descendants() iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		elem.walkDescendants(func(e *Element) error {
+			if yield(e) == false {
+				return ErrBreak
+			}
+			return nil
+		})
+	}
+}
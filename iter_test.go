@@ -0,0 +1,88 @@
+//go:build go1.23
+
+package dom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecords(t *testing.T) {
+	input := `<root><record id="1"/><group><record id="2"><record id="nested"/></record></group><other/><record id="3"/></root>`
+
+	var ids []string
+	for rec, err := range Records(strings.NewReader(input), "record") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, rec.FindAttr("id").Value)
+	}
+	if strings.Join(ids, ",") != "1,2,3" {
+		t.Fatal(ids)
+	}
+
+	count := 0
+	for range Records(strings.NewReader(input), "record") {
+		if count++; count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Fatal(`the iteration must stop on break`)
+	}
+
+	var errs []error
+	for rec, err := range Records(strings.NewReader(`<root><record/><record>`), "record") {
+		if err != nil {
+			errs = append(errs, err)
+		} else if rec == nil {
+			t.Fatal(`rec == nil`)
+		}
+	}
+	if len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
+
+func TestElements(t *testing.T) {
+	elem := Must(`<a>text<b><x/></b><!--comment--><c/><d/></a>`)
+
+	var names []string
+	for child := range elem.Elements() {
+		names = append(names, child.Name.Local)
+		if child.Name.Local == "c" {
+			break
+		}
+	}
+	if strings.Join(names, ",") != "b,c" {
+		t.Fatal(names)
+	}
+
+	var null *Element
+	for range null.Elements() {
+		t.Fatal(`nil must not have children`)
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	elem := Must(`<a><b><x><y/></x></b><c><z/></c></a>`)
+
+	var names []string
+	for e := range elem.Descendants() {
+		names = append(names, e.Name.Local)
+	}
+	if strings.Join(names, ",") != "b,x,y,c,z" {
+		t.Fatal(names)
+	}
+
+	names = nil
+	for e := range elem.Descendants() {
+		if e.Name.Local == "c" {
+			break
+		}
+		names = append(names, e.Name.Local)
+	}
+	if strings.Join(names, ",") != "b,x,y" {
+		t.Fatal(names)
+	}
+}
@@ -0,0 +1,200 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidJSON is returned when a JSON value does not represent a node.
+	ErrInvalidJSON = errors.New("Invalid JSON node")
+)
+
+// jsonNode is the JSON representation of the nodes. See MarshalJSON for the details.
+type jsonNode struct {
+	Name      *string           `json:"name,omitempty"`
+	Space     string            `json:"ns,omitempty"`
+	Attr      jsonAttrs         `json:"attr,omitempty"`
+	Children  []json.RawMessage `json:"children,omitempty"`
+	Comment   *string           `json:"comment,omitempty"`
+	CData     *string           `json:"cdata,omitempty"`
+	Directive *string           `json:"directive,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface. An element is encoded as an object like
+//
+//	{"name":"a","ns":"urn:x","attr":{"id":"1","{urn:y}lang":"en"},"children":["text",{"name":"b"}]}
+//
+// where "ns" is Name.Space, and "attr" holds the attributes in order keyed by Name.Local, or by
+// "{Name.Space}Name.Local" if Name.Space is not empty. Each of "ns", "attr" and "children" is omitted
+// if it is empty. The children are encoded in order so that mixed content is kept as it is:
+//   - xml.CharData is a string.
+//   - *Element is an object as above.
+//   - xml.Comment, CData and xml.Directive are objects like {"comment":"..."}, {"cdata":"..."} and {"directive":"..."}.
+//
+// A nil elem is encoded as null.
+func (elem *Element) MarshalJSON() ([]byte, error) {
+	if elem == nil {
+		return []byte("null"), nil
+	}
+
+	node := jsonNode{Name: &elem.Name.Local, Space: elem.Name.Space, Attr: elem.Attr}
+	for _, child := range elem.Children {
+		var v interface{}
+		switch child := child.(type) {
+		case *Element:
+			v = child
+		case xml.CharData:
+			v = string(child)
+		case xml.Comment:
+			s := string(child)
+			v = jsonNode{Comment: &s}
+		case CData:
+			s := string(child)
+			v = jsonNode{CData: &s}
+		case xml.Directive:
+			s := string(child)
+			v = jsonNode{Directive: &s}
+		default:
+			continue
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, b)
+	}
+
+	return json.Marshal(node)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface. It decodes the encoding of MarshalJSON,
+// and it resets elem in the same manner as UnmarshalXML. It returns ErrInvalidJSON if data or any of
+// its children does not represent a node, e.g. an object without "name" where an element is expected.
+func (elem *Element) UnmarshalJSON(data []byte) (err error) {
+	var node jsonNode
+	if err = json.Unmarshal(data, &node); err != nil {
+		return
+	}
+
+	if node.Name == nil {
+		return ErrInvalidJSON
+	}
+
+	elem.Name = xml.Name{Space: node.Space, Local: *node.Name}
+	elem.Attr = node.Attr
+	elem.Children = nil
+
+	for _, raw := range node.Children {
+		var child Node
+		if child, err = unmarshalJSONNode(raw); err != nil {
+			return
+		}
+		setParent(child, elem)
+		elem.Children = append(elem.Children, child)
+	}
+	return
+}
+
+func unmarshalJSONNode(raw json.RawMessage) (res Node, err error) {
+	if raw = bytes.TrimSpace(raw); len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return
+		}
+		return xml.CharData(s), nil
+	}
+
+	var node jsonNode
+	if err = json.Unmarshal(raw, &node); err != nil {
+		return
+	}
+
+	switch {
+	case node.Name != nil:
+		elem := &Element{}
+		if err = elem.UnmarshalJSON(raw); err != nil {
+			return
+		}
+		return elem, nil
+	case node.Comment != nil:
+		return xml.Comment(*node.Comment), nil
+	case node.CData != nil:
+		return CData(*node.CData), nil
+	case node.Directive != nil:
+		return xml.Directive(*node.Directive), nil
+	}
+	return nil, ErrInvalidJSON
+}
+
+// jsonAttrs encodes the attributes as an object while keeping their order.
+type jsonAttrs []xml.Attr
+
+func (attrs jsonAttrs) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, attr := range attrs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		key := attr.Name.Local
+		if len(attr.Name.Space) > 0 {
+			key = "{" + attr.Name.Space + "}" + key
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(attr.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (attrs *jsonAttrs) UnmarshalJSON(data []byte) (err error) {
+	*attrs = nil
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	next, err := d.Token()
+	if err != nil || next == nil {
+		return
+	}
+
+	if next != json.Delim('{') {
+		return ErrInvalidJSON
+	}
+
+	for d.More() == true {
+		if next, err = d.Token(); err != nil {
+			return
+		}
+
+		var attr xml.Attr
+		attr.Name.Local = next.(string)
+		if strings.HasPrefix(attr.Name.Local, "{") == true {
+			if i := strings.IndexByte(attr.Name.Local, '}'); i > 0 {
+				attr.Name = xml.Name{Space: attr.Name.Local[1:i], Local: attr.Name.Local[i+1:]}
+			}
+		}
+
+		if err = d.Decode(&attr.Value); err != nil {
+			return
+		}
+		*attrs = append(*attrs, attr)
+	}
+	return
+}
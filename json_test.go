@@ -0,0 +1,62 @@
+package dom
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	input := `<a xmlns:y="urn:y" id="1" y:lang="en">text<b/><!--comment--><c x="2">more</c></a>`
+	elem := Must(input)
+
+	b, err := json.Marshal(elem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"name":"a","attr":{"{xmlns}y":"urn:y","id":"1","{urn:y}lang":"en"},"children":["text",{"name":"b"},{"comment":"comment"},{"name":"c","attr":{"x":"2"},"children":["more"]}]}`
+	if string(b) != expected {
+		t.Fatal(string(b))
+	}
+
+	res := &Element{}
+	if err = json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.EqualOrdered(elem) == false {
+		t.Fatal(`the JSON encoding must round-trip`)
+	}
+	if res.Children[1].(*Element).Parent != res {
+		t.Fatal(`Parent must be set`)
+	}
+}
+
+func TestMarshalJSONNodes(t *testing.T) {
+	elem := &Element{}
+	if err := UnmarshalWith([]byte(`<a><![CDATA[<x>]]><!DOCTYPE b></a>`), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(elem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"a","children":[{"cdata":"\u003cx\u003e"},{"directive":"DOCTYPE b"}]}` {
+		t.Fatal(string(b))
+	}
+
+	res := &Element{}
+	if err = json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Children[0] != CData("<x>") || string(res.Children[1].(xml.Directive)) != "DOCTYPE b" {
+		t.Fatal(res.Children)
+	}
+
+	for _, input := range []string{`{"attr":{}}`, `{"name":"a","children":[{}]}`, `{"name":"a","attr":[]}`} {
+		if err := json.Unmarshal([]byte(input), &Element{}); err == nil {
+			t.Fatal(input)
+		}
+	}
+}
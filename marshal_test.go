@@ -0,0 +1,348 @@
+package dom
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestMarshalIndentSelfClosing(t *testing.T) {
+	elem := Must(`<a><b x="1"></b><!--<c></c>--><d>text</d><e><f/></e></a>`)
+	res, err := elem.MarshalIndent("", " ", false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<a>
+ <b x="1" /><!--<c></c>-->
+ <d>text</d>
+ <e>
+  <f />
+ </e>
+</a>`
+	if res != expected {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalSelfClosingText(t *testing.T) {
+	// Empty elements are collapsed by the encoder, so the markup in texts is never affected.
+	input := `<a>some &lt;b&gt;&lt;/b&gt; markup</a>`
+	res, err := Must(input).MarshalIndent("", " ", false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != input {
+		t.Fatal(res)
+	}
+
+	input = `<a><![CDATA[<div></div>]]><b>&gt;&lt;/div&gt;</b></a>`
+	elem := &Element{}
+	if err = UnmarshalWith([]byte(input), elem, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ = elem.MarshalOpts(MarshalOptions{SelfClosingEmpty: true}); res != input {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalIndentLineCount(t *testing.T) {
+	elem := Must(`<a><b x="1"/><c>text</c><d><e/></d></a>`)
+	for _, withDecl := range []bool{false, true} {
+		res, err := elem.MarshalIndent("", "  ", withDecl, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := elem.MarshalIndentLineCount("", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withDecl == true {
+			n++
+		}
+		if n != strings.Count(res, "\n")+1 {
+			t.Fatalf(`MarshalIndentLineCount() == %d`, n)
+		}
+	}
+
+	if n, _ := elem.MarshalIndentLineCount("", ""); n != 1 {
+		t.Fatalf(`MarshalIndentLineCount() == %d`, n)
+	}
+
+	elem = nil
+	if n, _ := elem.MarshalIndentLineCount("", "  "); n != 0 {
+		t.Fatalf(`MarshalIndentLineCount() == %d`, n)
+	}
+}
+
+func TestMarshalMaxRune(t *testing.T) {
+	elem := Must(`<café title="naïve 日本"><!--ünchanged-->crème brûlée<b>😀</b></café>`)
+	res, err := elem.marshal(MarshalOptions{Indent: " ", MaxRune: 0x7F})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<café title="na&#x00EF;ve &#x65E5;&#x672C;"><!--ünchanged-->cr&#x00E8;me br&#x00FB;l&#x00E9;e
+ <b>&#x1F600;</b>
+</café>`
+	if res != expected {
+		t.Fatal(res)
+	}
+
+	elem = Must(res)
+	if text, _ := elem.Children[1].(xml.CharData); string(text) != "crème brûlée" {
+		t.Fatal(string(text))
+	}
+	if elem.FindAttr("title").Value != "naïve 日本" {
+		t.Fatal(elem.FindAttr("title").Value)
+	}
+}
+
+// chunkWriter records the chunks written to it.
+type chunkWriter struct {
+	chunks []string
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.chunks = append(w.chunks, string(p))
+	return len(p), nil
+}
+
+func TestWriteOpts(t *testing.T) {
+	elem := &Element{Name: xml.Name{Local: "root"}}
+	for i := 0; i < 500; i++ {
+		item := &Element{Name: xml.Name{Local: "item"}, Attr: []xml.Attr{{Name: xml.Name{Local: "v"}, Value: `"é"`}}}
+		item.Children = []Node{&Element{Name: xml.Name{Local: "empty"}}, xml.CharData("text"), CData("<cdata>")}
+		elem.Children = append(elem.Children, item)
+	}
+
+	opts := MarshalOptions{Indent: "  ", WithDecl: true, MaxRune: 0x7F}
+	want, err := elem.marshal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var w chunkWriter
+	n, err := elem.WriteOpts(&w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.chunks) < 2 {
+		t.Fatal(`len(w.chunks) < 2`)
+	}
+	if res := strings.Join(w.chunks, ""); res != want || n != int64(len(want)) {
+		t.Fatal(res)
+	}
+
+	var b strings.Builder
+	if _, err = Must(`<a x="1"><b/></a>`).WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != `<a x="1"><b></b></a>` {
+		t.Fatal(b.String())
+	}
+}
+
+func TestMarshalOpts(t *testing.T) {
+	elem := Must(`<a x="'"><b/><c>"text"</c></a>`)
+
+	want, _ := elem.Marshal(false, true)
+	if res, err := elem.MarshalOpts(MarshalOptions{EscapeApos: true}); err != nil || res != want {
+		t.Fatal(res)
+	}
+
+	want, _ = elem.MarshalIndent("", "\t", true, true, false)
+	if res, err := elem.MarshalOpts(MarshalOptions{Indent: "\t", WithDecl: true, EscapeQuot: true}); err != nil || res != want {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalSelfClosingEmpty(t *testing.T) {
+	elem := Must(`<a><b x="1"></b><c>&gt;&lt;/x&gt;</c><d><!----></d><e><f/></e></a>`)
+
+	res, err := elem.MarshalOpts(MarshalOptions{SelfClosingEmpty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<a><b x="1" /><c>&gt;&lt;/x&gt;</c><d><!----></d><e><f /></e></a>` {
+		t.Fatal(res)
+	}
+
+	if res, _ = elem.MarshalOpts(MarshalOptions{}); res != `<a><b x="1"></b><c>&gt;&lt;/x&gt;</c><d><!----></d><e><f></f></e></a>` {
+		t.Fatal(res)
+	}
+
+	// MarshalIndent collapses empty elements even if the output is not indented
+	if res, _ = elem.MarshalIndent("", "", false, false, false); res != `<a><b x="1" /><c>&gt;&lt;/x&gt;</c><d><!----></d><e><f /></e></a>` {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalSortAttr(t *testing.T) {
+	a := Must(`<a z="1" y="2"><b n="1" m="2" xml:lang="en"/></a>`)
+	b := Must(`<a y="2" z="1"><b xml:lang="en" m="2" n="1"/></a>`)
+
+	opts := MarshalOptions{SortAttr: true}
+	resA, err := a.MarshalOpts(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resB, _ := b.MarshalOpts(opts)
+	if resA != resB || resA != `<a y="2" z="1"><b m="2" n="1" xml:lang="en"></b></a>` {
+		t.Fatal(resA, resB)
+	}
+
+	if a.Attr[0].Name.Local != "z" {
+		t.Fatal(`the element must not be modified`)
+	}
+}
+
+func TestMarshalDecl(t *testing.T) {
+	elem := Must(`<a><b/></a>`)
+
+	res, err := elem.MarshalOpts(MarshalOptions{Indent: " ", WithDecl: true, Decl: `<?xml version="1.0" encoding="utf-8" standalone="yes"?>`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<a>
+ <b />
+</a>` {
+		t.Fatal(res)
+	}
+
+	if res, _ = elem.MarshalOpts(MarshalOptions{WithDecl: true}); res != `<?xml version="1.0" encoding="utf-8"?>
+<a><b></b></a>` {
+		t.Fatal(res)
+	}
+
+	if res, _ = elem.MarshalOpts(MarshalOptions{Decl: `<?xml version="1.0"?>`}); res != `<a><b></b></a>` {
+		t.Fatal(`Decl must be ignored without WithDecl`)
+	}
+}
+
+func TestInnerXML(t *testing.T) {
+	elem := &Element{}
+	if err := UnmarshalWith([]byte(`<p xmlns:x="urn:x">Hello, <b>world</b>! <!--note--><x:br/></p>`), elem, DecodeOptions{PreserveWhitespace: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := elem.InnerXML(MarshalOptions{SelfClosingEmpty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `Hello, <b>world</b>! <!--note--><x:br />` {
+		t.Fatal(res)
+	}
+
+	if res, _ = Must(`<a><b/><c/></a>`).InnerXML(MarshalOptions{Indent: " ", WithDecl: true}); res != "<b />\n<c />" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res, err = elem.InnerXML(MarshalOptions{}); res != "" || err != nil {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalMaxAttrPerLine(t *testing.T) {
+	elem := Must(`<project><target name="build" depends="init" if="x" unless="y" description="Build it"/><item a="1"/></project>`)
+
+	res, err := elem.MarshalOpts(MarshalOptions{Indent: "\t", MaxAttrPerLine: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "<project>\n" +
+		"\t<target\n" +
+		"\t name=\"build\"\n" +
+		"\t depends=\"init\"\n" +
+		"\t if=\"x\"\n" +
+		"\t unless=\"y\"\n" +
+		"\t description=\"Build it\" />\n" +
+		"\t<item a=\"1\" />\n" +
+		"</project>"
+	if res != expected {
+		t.Fatal(res)
+	}
+
+	if Must(res).EqualOrdered(elem) == false {
+		t.Fatal(`the output must be decoded to the same tree`)
+	}
+
+	if res, _ = elem.MarshalOpts(MarshalOptions{Indent: "\t", MaxAttrPerLine: 5}); strings.Count(res, "\n") != 3 {
+		t.Fatal(res)
+	}
+
+	elem = Must(`<a><item a="1" b="2"/><item a="1" b="2"/><item a="1" b="2"/></a>`)
+	if res, _ = elem.MarshalOpts(MarshalOptions{MaxAttrPerLine: 1}); res != "<a>"+strings.Repeat("<item\n a=\"1\"\n b=\"2\"></item>", 3)+"</a>" {
+		t.Fatal(res)
+	}
+
+	elem = &Element{Name: xml.Name{Local: "root"}}
+	for i := 0; i < 2000; i++ {
+		elem.Children = append(elem.Children, Must(`<item a="1" b="2"><x c="3" d="4"/></item>`))
+	}
+	for _, opts := range []MarshalOptions{{MaxAttrPerLine: 1}, {Indent: "  ", MaxAttrPerLine: 1}} {
+		want, _ := elem.MarshalOpts(opts)
+		var b strings.Builder
+		if _, err = elem.WriteOpts(&b, opts); err != nil || b.String() != want {
+			t.Fatal(`WriteOpts() != MarshalOpts()`)
+		}
+		if len(want) > 100*len(elem.Children) {
+			t.Fatal(len(want))
+		}
+	}
+}
+
+func TestMarshalUnescapeGT(t *testing.T) {
+	elem := &Element{
+		Name:     xml.Name{Local: "a"},
+		Attr:     []xml.Attr{{Name: xml.Name{Local: "x"}, Value: "1>0\n2\t3"}},
+		Children: []Node{xml.CharData("b > a]]>c\nd")},
+	}
+
+	if res, _ := elem.MarshalOpts(MarshalOptions{}); res != "<a x=\"1&gt;0&#xA;2&#x9;3\">b &gt; a]]&gt;c\nd</a>" {
+		t.Fatal(res)
+	}
+
+	res, _ := elem.MarshalOpts(MarshalOptions{UnescapeGT: true})
+	if res != "<a x=\"1>0&#xA;2&#x9;3\">b > a]]&gt;c\nd</a>" {
+		t.Fatal(res)
+	}
+	if Must(res).Equal(elem) == false {
+		t.Fatal(`the output must be decoded to the same tree`)
+	}
+
+	if res, _ := elem.MarshalOpts(MarshalOptions{UnescapeNewlineInAttr: true}); res != "<a x=\"1&gt;0\n2&#x9;3\">b &gt; a]]&gt;c\nd</a>" {
+		t.Fatal(res)
+	}
+}
+
+func TestMarshalAttrQuote(t *testing.T) {
+	elem := Must(`<a x="1" y='say "hi"' z="it's"><b xmlns:p="urn:p" p:c="&lt;&amp;"/>it's "text"</a>`)
+
+	res, err := elem.MarshalOpts(MarshalOptions{AttrQuote: '\''})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<a x='1' y='say "hi"' z='it&apos;s'><b xmlns:p='urn:p' p:c='&lt;&amp;'></b>it's "text"</a>` {
+		t.Fatal(res)
+	}
+	if Must(res).EqualOrdered(elem) == false {
+		t.Fatal(`the output must be decoded to the same tree`)
+	}
+
+	res, _ = elem.MarshalOpts(MarshalOptions{AttrQuote: '\'', EscapeQuot: true, EscapeApos: true, Indent: " ", MaxAttrPerLine: 2})
+	if res != "<a\n x='1'\n y='say &#34;hi&#34;'\n z='it&#39;s'>\n <b xmlns:p='urn:p' p:c='&lt;&amp;' />it&#39;s &#34;text&#34;\n</a>" {
+		t.Fatal(res)
+	}
+	if Must(res).EqualOrdered(elem) == false {
+		t.Fatal(`the output must be decoded to the same tree`)
+	}
+
+	if res, _ := elem.MarshalOpts(MarshalOptions{AttrQuote: '`'}); strings.HasPrefix(res, `<a x="1"`) == false {
+		t.Fatal(res)
+	}
+}
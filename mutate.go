@@ -0,0 +1,443 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrOutOfRange is returned when an index is out of the range of Children.
+	ErrOutOfRange = errors.New("Index out of range")
+)
+
+// InsertChildAt inserts n into Children at index i, shifting the subsequent children.
+// i == len(elem.Children) appends n to the end. It returns ErrOutOfRange if i is out of
+// the range or elem is nil.
+func (elem *Element) InsertChildAt(i int, n Node) error {
+	if elem == nil || i < 0 || i > len(elem.Children) {
+		return ErrOutOfRange
+	}
+
+	elem.Children = append(elem.Children, nil)
+	copy(elem.Children[i+1:], elem.Children[i:])
+	elem.Children[i] = n
+	setParent(n, elem)
+
+	return nil
+}
+
+// CoalesceChildren merges each run of consecutive child elements whose Name.Local is name into the
+// first element of the run. The children of the following elements are appended to the first one,
+// and the following elements are removed. Any other node between them breaks the run.
+//
+// The first element wins on attribute conflicts: attributes of the following elements are added only
+// if the first element does not have an attribute with the same local name yet.
+func (elem *Element) CoalesceChildren(name string) {
+	if elem == nil {
+		return
+	}
+
+	var head *Element
+	children := elem.Children[:0]
+	for _, child := range elem.Children {
+		childElem, ok := child.(*Element)
+		if ok == false || childElem.Name.Local != name {
+			head = nil
+			children = append(children, child)
+			continue
+		}
+
+		if head == nil {
+			head = childElem
+			children = append(children, child)
+			continue
+		}
+
+		for _, attr := range childElem.Attr {
+			if head.HasAttr(attr.Name.Local) == false {
+				head.Attr = append(head.Attr, attr)
+			}
+		}
+		for _, grandchild := range childElem.Children {
+			setParent(grandchild, head)
+		}
+		head.Children = append(head.Children, childElem.Children...)
+	}
+
+	for i := len(children); i < len(elem.Children); i++ {
+		elem.Children[i] = nil
+	}
+	elem.Children = children
+}
+
+// ReorderChildren stably reorders the child elements so that their Name.Local follow order.
+// The elements whose names are not listed in order keep their relative order and are placed after
+// all the listed ones. Non-element nodes such as texts and comments stay at their positions in Children,
+// and the child elements are redistributed among the remaining positions.
+func (elem *Element) ReorderChildren(order []string) {
+	if elem == nil {
+		return
+	}
+
+	rank := make(map[string]int, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		rank[order[i]] = i
+	}
+
+	rankOf := func(e *Element) int {
+		if i, ok := rank[e.Name.Local]; ok == true {
+			return i
+		}
+		return len(order)
+	}
+
+	var elems []*Element
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			elems = append(elems, childElem)
+		}
+	}
+
+	sort.SliceStable(elems, func(i, j int) bool {
+		return rankOf(elems[i]) < rankOf(elems[j])
+	})
+
+	i := 0
+	for j, child := range elem.Children {
+		if _, ok := child.(*Element); ok == true {
+			elem.Children[j] = elems[i]
+			i++
+		}
+	}
+}
+
+// TakeChildren returns Children and sets it to nil, which transfers the ownership of the child
+// nodes to the caller without copying them. Parent of the returned elements is cleared.
+func (elem *Element) TakeChildren() (res []Node) {
+	if elem == nil {
+		return
+	}
+
+	res, elem.Children = elem.Children, nil
+	for _, child := range res {
+		setParent(child, nil)
+	}
+	return
+}
+
+// RemoveWhere removes every descendant element of elem where pred returns true, and returns the number
+// of removed elements. elem itself is never removed.
+//
+// pred is evaluated in document order (preorder). When an element is removed, its whole subtree is removed
+// with it and pred is not evaluated on its descendants. Children of each element is rebuilt in place
+// once all of its children have been examined.
+func (elem *Element) RemoveWhere(pred func(*Element) bool) (res int) {
+	if elem == nil {
+		return
+	}
+
+	children := elem.Children[:0]
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			if pred(childElem) == true {
+				childElem.Parent = nil
+				res++
+				continue
+			}
+			res += childElem.RemoveWhere(pred)
+		}
+		children = append(children, child)
+	}
+
+	for i := len(children); i < len(elem.Children); i++ {
+		elem.Children[i] = nil
+	}
+	elem.Children = children
+
+	return
+}
+
+// RemoveChildrenNamed removes every child element whose Name.Local is name, and returns the number
+// of removed elements. Texts, comments and directives are left untouched.
+func (elem *Element) RemoveChildrenNamed(name string) int {
+	return elem.RemoveChildrenPred(func(child *Element) bool {
+		return child.Name.Local == name
+	})
+}
+
+// RemoveChildrenPred removes every child element where pred returns true, and returns the number
+// of removed elements. Unlike RemoveWhere, only the direct children are examined. Texts, comments and
+// directives are left untouched.
+func (elem *Element) RemoveChildrenPred(pred func(child *Element) bool) (res int) {
+	if elem == nil {
+		return
+	}
+
+	children := elem.Children[:0]
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true && pred(childElem) == true {
+			childElem.Parent = nil
+			res++
+			continue
+		}
+		children = append(children, child)
+	}
+
+	for i := len(children); i < len(elem.Children); i++ {
+		elem.Children[i] = nil
+	}
+	elem.Children = children
+
+	return
+}
+
+// WrapEachNamed wraps each child element whose Name.Local is name in a new element named wrapperName
+// in place, and returns the number of wrapped elements. The order of Children is preserved and
+// the wrappers have no attributes.
+func (elem *Element) WrapEachNamed(name, wrapperName string) (res int) {
+	if elem == nil {
+		return
+	}
+
+	for i, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true && childElem.Name.Local == name {
+			wrapper := &Element{Name: xml.Name{Local: wrapperName}, Children: []Node{childElem}, Parent: elem}
+			childElem.Parent = wrapper
+			elem.Children[i] = wrapper
+			res++
+		}
+	}
+	return
+}
+
+// AppendChild appends n to Children. Nothing happens if elem or n is nil, including a nil *Element.
+func (elem *Element) AppendChild(n Node) {
+	if elem == nil || n == nil {
+		return
+	}
+	if childElem, ok := n.(*Element); ok == true && childElem == nil {
+		return
+	}
+
+	elem.Children = append(elem.Children, n)
+	setParent(n, elem)
+}
+
+// RemoveChild removes the first node in Children which is identical to n, and reports whether
+// it removed anything. *Element nodes are compared by pointer identity, while xml.CharData,
+// xml.Comment and xml.Directive nodes are compared by their types and contents.
+func (elem *Element) RemoveChild(n Node) bool {
+	i := elem.indexOfChild(n)
+	if i < 0 {
+		return false
+	}
+
+	setParent(elem.Children[i], nil)
+	copy(elem.Children[i:], elem.Children[i+1:])
+	elem.Children[len(elem.Children)-1] = nil
+	elem.Children = elem.Children[:len(elem.Children)-1]
+	return true
+}
+
+// AdoptChild moves child from src.Children to the end of elem.Children and updates child.Parent,
+// and reports whether it moved child. When src == elem, child is moved to the end of Children.
+// Nothing happens if elem is nil, child is not a child of src, or child is elem or one of its ancestors
+// (see Ancestors), which would make a cycle.
+func (elem *Element) AdoptChild(child *Element, src *Element) bool {
+	if elem == nil || child == nil {
+		return false
+	}
+
+	for p := elem; p != nil; p = p.Parent {
+		if p == child {
+			return false
+		}
+	}
+
+	if src.RemoveChild(child) == false {
+		return false
+	}
+
+	elem.AppendChild(child)
+	return true
+}
+
+// InsertBefore inserts newNode into Children right before the first node identical to ref,
+// and reports whether ref was found. See RemoveChild for how nodes are compared.
+func (elem *Element) InsertBefore(newNode, ref Node) bool {
+	i := elem.indexOfChild(ref)
+	if i < 0 {
+		return false
+	}
+
+	return elem.InsertChildAt(i, newNode) == nil
+}
+
+func (elem *Element) indexOfChild(n Node) int {
+	if elem == nil {
+		return -1
+	}
+
+	for i, child := range elem.Children {
+		if sameNode(child, n) == true {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameNode reports whether a and b are identical. Note that byte slices such as xml.CharData
+// cannot be compared with == as interface values.
+func sameNode(a, b Node) bool {
+	switch a := a.(type) {
+	case *Element:
+		b, ok := b.(*Element)
+		return ok == true && a == b
+	case xml.CharData:
+		b, ok := b.(xml.CharData)
+		return ok == true && bytes.Equal(a, b)
+	case xml.Comment:
+		b, ok := b.(xml.Comment)
+		return ok == true && bytes.Equal(a, b)
+	case xml.Directive:
+		b, ok := b.(xml.Directive)
+		return ok == true && bytes.Equal(a, b)
+	case CData:
+		b, ok := b.(CData)
+		return ok == true && a == b
+	}
+	return false
+}
+
+// Clone returns a deep copy of elem. The copy shares no backing arrays with elem, i.e. Attr,
+// Children and the contents of xml.CharData, xml.Comment and xml.Directive nodes are all copied.
+// The copy is detached from the tree, i.e. its Parent is nil. It returns nil if elem is nil.
+func (elem *Element) Clone() *Element {
+	if elem == nil {
+		return nil
+	}
+
+	res := &Element{Name: elem.Name}
+	if elem.Attr != nil {
+		res.Attr = make([]xml.Attr, len(elem.Attr))
+		copy(res.Attr, elem.Attr)
+	}
+
+	if elem.Children != nil {
+		res.Children = make([]Node, len(elem.Children))
+		for i, child := range elem.Children {
+			res.Children[i] = cloneNode(child)
+			setParent(res.Children[i], res)
+		}
+	}
+
+	return res
+}
+
+func cloneNode(n Node) Node {
+	switch node := n.(type) {
+	case *Element:
+		return node.Clone()
+	case xml.CharData, xml.Comment, xml.Directive:
+		return xml.CopyToken(node)
+	}
+	return n
+}
+
+// setParent sets Parent of n to parent if n is an *Element.
+func setParent(n Node, parent *Element) {
+	if childElem, ok := n.(*Element); ok == true {
+		childElem.Parent = parent
+	}
+}
+
+// Ancestors returns the ancestors of elem from the immediate parent up to the root element by following
+// Parent. See Element.Parent for when it is valid.
+func (elem *Element) Ancestors() (res []*Element) {
+	if elem == nil {
+		return
+	}
+
+	for parent := elem.Parent; parent != nil; parent = parent.Parent {
+		res = append(res, parent)
+	}
+	return
+}
+
+// Normalize merges the adjacent xml.CharData children into one and removes the empty ones
+// in elem and all of its descendants, like Node.normalize() of DOM. It makes Text work on
+// the elements whose text was built by appending pieces. CData nodes are neither merged nor removed.
+func (elem *Element) Normalize() {
+	if elem == nil {
+		return
+	}
+
+	res := elem.Children[:0]
+	for _, child := range elem.Children {
+		switch node := child.(type) {
+		case xml.CharData:
+			if len(node) == 0 {
+				continue
+			}
+
+			if n := len(res); n > 0 {
+				if prev, ok := res[n-1].(xml.CharData); ok == true {
+					res[n-1] = append(append(xml.CharData{}, prev...), node...)
+					continue
+				}
+			}
+		case *Element:
+			node.Normalize()
+		}
+		res = append(res, child)
+	}
+
+	for i := len(res); i < len(elem.Children); i++ {
+		elem.Children[i] = nil
+	}
+	elem.Children = res
+}
+
+// TrimSpace trims the leading and trailing whitespaces of each xml.CharData child of elem and all of its
+// descendants, and removes the ones which become empty. The whitespaces inside texts are kept as they are.
+// It works like the trimming of UnmarshalXML, but on a tree already built. CData nodes are not changed.
+func (elem *Element) TrimSpace() {
+	elem.trimSpace(strings.TrimSpace)
+}
+
+// CollapseSpace works like TrimSpace, but it also collapses each run of whitespaces inside texts into
+// a single space, e.g. "a b" for "\n  a\n  b\n".
+func (elem *Element) CollapseSpace() {
+	elem.trimSpace(func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	})
+}
+
+func (elem *Element) trimSpace(trim func(string) string) {
+	if elem == nil {
+		return
+	}
+
+	res := elem.Children[:0]
+	for _, child := range elem.Children {
+		switch node := child.(type) {
+		case xml.CharData:
+			text := trim(string(node))
+			if len(text) == 0 {
+				continue
+			}
+			child = xml.CharData(text)
+		case *Element:
+			node.trimSpace(trim)
+		}
+		res = append(res, child)
+	}
+
+	for i := len(res); i < len(elem.Children); i++ {
+		elem.Children[i] = nil
+	}
+	elem.Children = res
+}
@@ -0,0 +1,355 @@
+package dom
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestInsertChildAt(t *testing.T) {
+	elem := &Element{}
+	if err := elem.InsertChildAt(0, &Element{Name: xml.Name{Local: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := elem.InsertChildAt(0, &Element{Name: xml.Name{Local: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := elem.InsertChildAt(2, xml.CharData("text")); err != nil {
+		t.Fatal(err)
+	}
+	if err := elem.InsertChildAt(1, xml.Comment("comment")); err != nil {
+		t.Fatal(err)
+	}
+
+	elem.Name.Local = "root"
+	if res, _ := elem.Marshal(false, false); res != `<root><a></a><!--comment--><b></b>text</root>` {
+		t.Fatal(res)
+	}
+
+	if err := elem.InsertChildAt(5, xml.CharData("x")); err != ErrOutOfRange {
+		t.Fatal(`err != ErrOutOfRange`)
+	}
+	if err := elem.InsertChildAt(-1, xml.CharData("x")); err != ErrOutOfRange {
+		t.Fatal(`err != ErrOutOfRange`)
+	}
+
+	elem = nil
+	if err := elem.InsertChildAt(0, xml.CharData("x")); err != ErrOutOfRange {
+		t.Fatal(`err != ErrOutOfRange`)
+	}
+}
+
+func TestCoalesceChildren(t *testing.T) {
+	elem := Must(`<a><s x="1"><b/></s><s x="2" y="3"><c/></s><s><d/></s><!--break--><s><e/></s><t/><s/></a>`)
+	elem.CoalesceChildren("s")
+
+	res, _ := elem.Marshal(false, false)
+	if res != `<a><s x="1" y="3"><b></b><c></c><d></d></s><!--break--><s><e></e></s><t></t><s></s></a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	elem.CoalesceChildren("s")
+}
+
+func TestReorderChildren(t *testing.T) {
+	elem := Must(`<a><x id="1"/><c/>text<b/><!--comment--><x id="2"/><a/><y/></a>`)
+	elem.ReorderChildren([]string{"a", "b", "c"})
+
+	res, _ := elem.Marshal(false, false)
+	if res != `<a><a></a><b></b>text<c></c><!--comment--><x id="1"></x><x id="2"></x><y></y></a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	elem.ReorderChildren([]string{"a"})
+}
+
+func TestTakeChildren(t *testing.T) {
+	src := Must(`<src><a/>text<b/></src>`)
+	dst := Must(`<dst/>`)
+
+	dst.Children = src.TakeChildren()
+	if len(src.Children) != 0 || src.IsEmpty() == false {
+		t.Fatal(`src must be emptied`)
+	}
+	if res, _ := dst.Marshal(false, false); res != `<dst><a></a>text<b></b></dst>` {
+		t.Fatal(res)
+	}
+
+	src = nil
+	if len(src.TakeChildren()) != 0 {
+		t.Fatal(`nil elem must return an empty slice`)
+	}
+}
+
+func TestRemoveWhere(t *testing.T) {
+	elem := Must(`<a><x/><b>text<x><x/></x><c><x/></c></b><!--comment--></a>`)
+
+	var visited int
+	n := elem.RemoveWhere(func(e *Element) bool {
+		visited++
+		return e.Name.Local == "x"
+	})
+
+	if n != 3 {
+		t.Fatalf(`RemoveWhere() == %d`, n)
+	}
+	if visited != 5 {
+		t.Fatalf(`pred must not be evaluated on removed subtrees: %d`, visited)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a><b>text<c></c></b><!--comment--></a>` {
+		t.Fatal(res)
+	}
+
+	if n := elem.RemoveWhere(func(e *Element) bool { return e.Name.Local == "a" }); n != 0 {
+		t.Fatal(`elem itself must not be removed`)
+	}
+
+	elem = nil
+	if elem.RemoveWhere(func(e *Element) bool { return true }) != 0 {
+		t.Fatal(`nil elem must return 0`)
+	}
+}
+
+func TestWrapEachNamed(t *testing.T) {
+	elem := Must(`<list><item>1</item>text<item id="2"/><other/><item/></list>`)
+	if n := elem.WrapEachNamed("item", "entry"); n != 3 {
+		t.Fatalf(`WrapEachNamed() == %d`, n)
+	}
+
+	res, _ := elem.Marshal(false, false)
+	if res != `<list><entry><item>1</item></entry>text<entry><item id="2"></item></entry><other></other><entry><item></item></entry></list>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.WrapEachNamed("item", "entry") != 0 {
+		t.Fatal(`nil elem must return 0`)
+	}
+}
+
+func TestAppendRemoveInsertChild(t *testing.T) {
+	elem := Must(`<a/>`)
+	b, c, d := &Element{Name: xml.Name{Local: "b"}}, &Element{Name: xml.Name{Local: "c"}}, &Element{Name: xml.Name{Local: "d"}}
+	elem.AppendChild(b)
+	elem.AppendChild(xml.CharData("text"))
+	elem.AppendChild(c)
+	elem.AppendChild(d)
+
+	// Remove a middle child
+	if elem.RemoveChild(c) == false {
+		t.Fatal(`elem.RemoveChild(c) == false`)
+	}
+	if elem.RemoveChild(c) == true {
+		t.Fatal(`elem.RemoveChild(c) == true`)
+	}
+	if elem.RemoveChild(&Element{Name: xml.Name{Local: "d"}}) == true {
+		t.Fatal(`elements must be compared by pointer identity`)
+	}
+	if elem.RemoveChild(xml.CharData("text")) == false {
+		t.Fatal(`texts must be compared by content`)
+	}
+
+	// Insert before the first child
+	if elem.InsertBefore(c, b) == false {
+		t.Fatal(`elem.InsertBefore(c, b) == false`)
+	}
+	if elem.InsertBefore(xml.Comment("comment"), &Element{}) == true {
+		t.Fatal(`missing ref must not be found`)
+	}
+
+	if res, _ := elem.Marshal(false, false); res != `<a><c></c><b></b><d></d></a>` {
+		t.Fatal(res)
+	}
+
+	// Only the first matching node is removed
+	elem = Must(`<a><!--x--><b/><!--x--></a>`)
+	elem.RemoveChild(xml.Comment("x"))
+	if res, _ := elem.Marshal(false, false); res != `<a><b></b><!--x--></a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	elem.AppendChild(b)
+	if elem.RemoveChild(b) == true || elem.InsertBefore(c, b) == true {
+		t.Fatal(`nil elem must do nothing`)
+	}
+}
+
+func TestClone(t *testing.T) {
+	src := Must(`<a x="1"><b y="2">text</b><!--comment--></a>`)
+	clone := src.Clone()
+
+	clone.Attr[0].Value = "changed"
+	clone.Children[0].(*Element).Attr[0].Value = "changed"
+	copy(clone.Children[0].(*Element).Children[0].(xml.CharData), "TEXT")
+	copy(clone.Children[1].(xml.Comment), "COMMENT")
+	clone.Children[0].(*Element).SetText("replaced")
+
+	if res, _ := src.Marshal(false, false); res != `<a x="1"><b y="2">text</b><!--comment--></a>` {
+		t.Fatal(res)
+	}
+	if res, _ := clone.Marshal(false, false); res != `<a x="changed"><b y="changed">replaced</b><!--COMMENT--></a>` {
+		t.Fatal(res)
+	}
+
+	src = nil
+	if src.Clone() != nil {
+		t.Fatal(`nil elem must return nil`)
+	}
+}
+
+func TestParent(t *testing.T) {
+	elem := Must(`<a><b><c/></b>text</a>`)
+	b := elem.Children[0].(*Element)
+	c := b.Children[0].(*Element)
+	if elem.Parent != nil || b.Parent != elem || c.Parent != b {
+		t.Fatal(`Parent must be populated during unmarshaling`)
+	}
+	if res := c.Ancestors(); len(res) != 2 || res[0] != b || res[1] != elem {
+		t.Fatal(res)
+	}
+
+	d := &Element{Name: xml.Name{Local: "d"}}
+	c.AppendChild(d)
+	if res := d.Ancestors(); len(res) != 3 || res[2] != elem {
+		t.Fatal(res)
+	}
+
+	b.RemoveChild(c)
+	if c.Parent != nil || len(d.Ancestors()) != 1 {
+		t.Fatal(`RemoveChild must clear Parent`)
+	}
+
+	elem.InsertBefore(c, b)
+	elem.WrapEachNamed("b", "w")
+	if c.Parent != elem || b.Parent.Name.Local != "w" || b.Parent.Parent != elem {
+		t.Fatal(`mutation helpers must maintain Parent`)
+	}
+
+	clone := elem.Clone()
+	if clone.Parent != nil || clone.Children[0].(*Element).Parent != clone {
+		t.Fatal(`Clone must maintain Parent`)
+	}
+
+	// Parent must not be marshaled
+	if res, _ := elem.Marshal(false, false); res != `<a><c><d></d></c><w><b></b></w>text</a>` {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if len(elem.Ancestors()) != 0 {
+		t.Fatal(`nil elem must return an empty slice`)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	elem := &Element{Name: xml.Name{Local: "a"}}
+	elem.AppendChild(xml.CharData("one, "))
+	elem.AppendChild(xml.CharData(""))
+	elem.AppendChild(xml.CharData("two, "))
+	elem.AppendChild(xml.CharData("three"))
+	if _, ok := elem.Text(); ok == true {
+		t.Fatal(`elem.Text() must fail before Normalize()`)
+	}
+
+	elem.Normalize()
+	if text, ok := elem.Text(); ok == false || text != "one, two, three" {
+		t.Fatal(text)
+	}
+
+	elem = Must(`<a><b/></a>`)
+	b := elem.Children[0].(*Element)
+	b.Children = []Node{xml.CharData("x"), xml.CharData("y"), xml.Comment("c"), xml.CharData(""), xml.CharData("z"), CData("w")}
+	elem.Normalize()
+	if res, _ := elem.Marshal(false, false); res != `<a><b>xy<!--c-->z<![CDATA[w]]></b></a>` || len(b.Children) != 4 {
+		t.Fatal(res)
+	}
+}
+
+func TestRemoveChildrenNamed(t *testing.T) {
+	elem := Must(`<a><b/>text<c><b/></c><!--comment--><b x="1"/><d/></a>`)
+	removed := elem.Children[0].(*Element)
+
+	if res := elem.RemoveChildrenNamed("b"); res != 2 {
+		t.Fatal(res)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a>text<c><b></b></c><!--comment--><d></d></a>` {
+		t.Fatal(res)
+	}
+	if removed.Parent != nil {
+		t.Fatal(`Parent of the removed element must be cleared`)
+	}
+
+	res := elem.RemoveChildrenPred(func(child *Element) bool {
+		return child.CountChildren() > 0
+	})
+	if res != 1 || len(elem.Children) != 3 {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if elem.RemoveChildrenNamed("b") != 0 {
+		t.Fatal(`nil must not have children`)
+	}
+}
+
+func TestAdoptChild(t *testing.T) {
+	elem := Must(`<a><src><b/><c/></src><dst><d/></dst></a>`)
+	src, dst := elem.FindChild("src"), elem.FindChild("dst")
+	b := src.FindChild("b")
+
+	if dst.AdoptChild(b, src) == false {
+		t.Fatal(`dst.AdoptChild(b, src) == false`)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a><src><c></c></src><dst><d></d><b></b></dst></a>` {
+		t.Fatal(res)
+	}
+	if b.Parent != dst {
+		t.Fatal(`b.Parent != dst`)
+	}
+
+	if dst.AdoptChild(b, src) == true {
+		t.Fatal(`b is not a child of src any more`)
+	}
+
+	// src == dst moves child to the end
+	if dst.AdoptChild(dst.FindChild("d"), dst) == false {
+		t.Fatal(`dst.AdoptChild(d, dst) == false`)
+	}
+	if res, _ := dst.Marshal(false, false); res != `<dst><b></b><d></d></dst>` {
+		t.Fatal(res)
+	}
+
+	if b.AdoptChild(dst, elem) == true {
+		t.Fatal(`an ancestor must not be adopted`)
+	}
+}
+
+func TestTrimSpace(t *testing.T) {
+	build := func() *Element {
+		elem := &Element{Name: xml.Name{Local: "a"}}
+		elem.AppendChild(xml.CharData("\n  hello   world \n"))
+		elem.AppendChild(xml.CharData(" \t "))
+		elem.AppendChild(CData("  raw  "))
+		b := &Element{Name: xml.Name{Local: "b"}, Children: []Node{xml.CharData("  x\n  y  ")}}
+		elem.AppendChild(b)
+		return elem
+	}
+
+	elem := build()
+	elem.TrimSpace()
+	if res, _ := elem.Marshal(false, false); res != "<a>hello   world<![CDATA[  raw  ]]><b>x\n  y</b></a>" {
+		t.Fatal(res)
+	}
+
+	elem = build()
+	elem.CollapseSpace()
+	if res, _ := elem.Marshal(false, false); res != "<a>hello world<![CDATA[  raw  ]]><b>x y</b></a>" {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	elem.TrimSpace()
+	elem.CollapseSpace()
+}
@@ -0,0 +1,200 @@
+package dom
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+const (
+	xmlnsPrefix = "xmlns"
+	xmlPrefix   = "xml"
+	xmlURL      = "http://www.w3.org/XML/1998/namespace"
+)
+
+// nsScope tracks the namespace declarations in scope while marshaling, so that the namespace URIs
+// in Name.Space are written back as the prefixes declared in the document.
+// xml.Encoder also supports Name.Space, but it emits a default namespace declaration on every element
+// and cannot handle the xmlns attributes decoded by xml.Decoder.
+type nsScope struct {
+	bindings  []nsBinding
+	generated int
+}
+
+type nsBinding struct {
+	prefix, uri string
+}
+
+// lookup returns the prefix bound to uri which is not shadowed by a later declaration.
+// The default namespace, i.e. the empty prefix, is considered only when withDefault is true.
+func (s *nsScope) lookup(uri string, withDefault bool) (string, bool) {
+	seen := map[string]bool{}
+	for i := len(s.bindings) - 1; i >= 0; i-- {
+		b := s.bindings[i]
+		if seen[b.prefix] == false && b.uri == uri && (withDefault == true || len(b.prefix) > 0) {
+			return b.prefix, true
+		}
+		seen[b.prefix] = true
+	}
+	return "", false
+}
+
+// defaultURI returns the default namespace in scope.
+func (s *nsScope) defaultURI() string {
+	for i := len(s.bindings) - 1; i >= 0; i-- {
+		if len(s.bindings[i].prefix) == 0 {
+			return s.bindings[i].uri
+		}
+	}
+	return ""
+}
+
+// declaration returns the prefix declared by attr if attr is a namespace declaration.
+func declaration(attr *xml.Attr) (prefix string, ok bool) {
+	switch {
+	case attr.Name.Space == xmlnsPrefix:
+		return attr.Name.Local, true
+	case len(attr.Name.Space) == 0 && attr.Name.Local == xmlnsPrefix:
+		return "", true
+	case len(attr.Name.Space) == 0 && strings.HasPrefix(attr.Name.Local, xmlnsPrefix+":"):
+		return attr.Name.Local[len(xmlnsPrefix)+1:], true
+	}
+	return "", false
+}
+
+// push enters elem and returns its start element with qualified names, which xml.Encoder writes as
+// they are. The returned mark must be passed to pop when leaving elem.
+func (s *nsScope) push(elem *Element) (start xml.StartElement, mark int) {
+	mark = len(s.bindings)
+	attrs := make([]xml.Attr, 0, len(elem.Attr))
+
+	for i := range elem.Attr {
+		attr := &elem.Attr[i]
+		if prefix, ok := declaration(attr); ok == true {
+			s.bindings = append(s.bindings, nsBinding{prefix, attr.Value})
+			name := xmlnsPrefix
+			if len(prefix) > 0 {
+				name += ":" + prefix
+			}
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: attr.Value})
+		}
+	}
+
+	start.Name.Local = elem.Name.Local
+	switch space := elem.Name.Space; {
+	case len(space) == 0:
+		if len(s.defaultURI()) > 0 {
+			s.bindings = append(s.bindings, nsBinding{"", ""})
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: xmlnsPrefix}})
+		}
+	case s.defaultURI() == space:
+	default:
+		if prefix, ok := s.lookup(space, false); ok == true {
+			start.Name.Local = prefix + ":" + elem.Name.Local
+		} else {
+			s.bindings = append(s.bindings, nsBinding{"", space})
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: xmlnsPrefix}, Value: space})
+		}
+	}
+
+	for i := range elem.Attr {
+		attr := elem.Attr[i]
+		if _, ok := declaration(&attr); ok == true {
+			continue
+		}
+
+		switch space := attr.Name.Space; {
+		case len(space) == 0:
+		case space == xmlURL:
+			attr.Name.Local = xmlPrefix + ":" + attr.Name.Local
+		default:
+			prefix, ok := s.lookup(space, false)
+			if ok == false {
+				prefix = s.generatePrefix()
+				s.bindings = append(s.bindings, nsBinding{prefix, space})
+				attrs = append(attrs, xml.Attr{Name: xml.Name{Local: xmlnsPrefix + ":" + prefix}, Value: space})
+			}
+			attr.Name.Local = prefix + ":" + attr.Name.Local
+		}
+		attr.Name.Space = ""
+		attrs = append(attrs, attr)
+	}
+
+	start.Attr = attrs
+	return
+}
+
+// pop leaves the element entered by push.
+func (s *nsScope) pop(mark int) {
+	s.bindings = s.bindings[:mark]
+}
+
+// generatePrefix returns a prefix like "ns1" which is not bound in scope.
+func (s *nsScope) generatePrefix() string {
+	for {
+		s.generated++
+		prefix := "ns" + strconv.Itoa(s.generated)
+
+		bound := false
+		for _, b := range s.bindings {
+			bound = bound || b.prefix == prefix
+		}
+		if bound == false {
+			return prefix
+		}
+	}
+}
+
+// DeclareNamespace declares the namespace uri with prefix by adding an xmlns:prefix attribute to elem,
+// or updating the existing one. An empty prefix declares the default namespace with an xmlns attribute.
+// Use SetName to name elem or its descendants with prefix.
+func (elem *Element) DeclareNamespace(prefix, uri string) {
+	if elem == nil {
+		return
+	}
+
+	name := xml.Name{Space: xmlnsPrefix, Local: prefix}
+	if len(prefix) == 0 {
+		name = xml.Name{Local: xmlnsPrefix}
+	}
+
+	for i := range elem.Attr {
+		if p, ok := declaration(&elem.Attr[i]); ok == true && p == prefix {
+			elem.Attr[i].Value = uri
+			return
+		}
+	}
+	elem.Attr = append(elem.Attr, xml.Attr{Name: name, Value: uri})
+}
+
+// SetName sets Name of elem to local in the namespace bound to prefix, which is declared by elem or its
+// ancestors (see DeclareNamespace and Parent), so that the marshal functions write it as "prefix:local".
+// An empty prefix means the default namespace in scope, or no namespace if there is none.
+// Name.Space is set to prefix itself if prefix is not declared, in the same manner as xml.Decoder.
+func (elem *Element) SetName(prefix, local string) {
+	if elem == nil {
+		return
+	}
+
+	uri, ok := elem.lookupNamespace(prefix)
+	if ok == false && len(prefix) > 0 {
+		uri = prefix
+	}
+	elem.Name = xml.Name{Space: uri, Local: local}
+}
+
+// lookupNamespace returns the namespace URI bound to prefix declared by elem or its ancestors.
+func (elem *Element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == xmlPrefix {
+		return xmlURL, true
+	}
+
+	for e := elem; e != nil; e = e.Parent {
+		for i := range e.Attr {
+			if p, ok := declaration(&e.Attr[i]); ok == true && p == prefix {
+				return e.Attr[i].Value, true
+			}
+		}
+	}
+	return "", false
+}
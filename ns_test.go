@@ -0,0 +1,106 @@
+package dom
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestNamespaceRoundTrip(t *testing.T) {
+	input := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body/></soap:Envelope>`
+	elem := Must(input)
+	if elem.Name.Space != "http://schemas.xmlsoap.org/soap/envelope/" || elem.Name.Local != "Envelope" {
+		t.Fatal(elem.Name)
+	}
+
+	res, err := elem.Marshal(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body></soap:Body></soap:Envelope>` {
+		t.Fatal(res)
+	}
+
+	// The namespace survives the round trip
+	body := Must(res).Children[0].(*Element)
+	if body.Name.Space != "http://schemas.xmlsoap.org/soap/envelope/" || body.Name.Local != "Body" {
+		t.Fatal(body.Name)
+	}
+
+	// xml.Marshal goes through MarshalXML
+	b, err := xml.Marshal(elem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body></soap:Body></soap:Envelope>` {
+		t.Fatal(string(b))
+	}
+}
+
+func TestNamespaceScope(t *testing.T) {
+	input := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:x="urn:x" xml:lang="en"><entry x:id="1"><x:ext><title xmlns="">t</title></x:ext></entry></feed>`
+	res, err := Must(input).Marshal(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:x="urn:x" xml:lang="en"><entry x:id="1"><x:ext><title xmlns="">t</title></x:ext></entry></feed>` {
+		t.Fatal(res)
+	}
+
+	// Namespaces without prefixes in scope
+	elem := &Element{
+		Name: xml.Name{Space: "urn:a", Local: "a"},
+		Attr: []xml.Attr{{Name: xml.Name{Space: "urn:b", Local: "b"}, Value: "1"}},
+		Children: []Node{
+			&Element{Name: xml.Name{Space: "urn:a", Local: "c"}},
+			&Element{Name: xml.Name{Local: "d"}},
+		},
+	}
+	if res, _ = elem.Marshal(false, false); res != `<a xmlns="urn:a" xmlns:ns1="urn:b" ns1:b="1"><c></c><d xmlns=""></d></a>` {
+		t.Fatal(res)
+	}
+	if Must(res).MatchesTemplate(elem) == false {
+		t.Fatal(`the output must be decoded to the same names`)
+	}
+}
+
+func TestDeclareNamespace(t *testing.T) {
+	envelope := NewElement("")
+	envelope.DeclareNamespace("soap", "http://schemas.xmlsoap.org/soap/envelope/")
+	envelope.SetName("soap", "Envelope")
+
+	body := NewElement("")
+	envelope.AppendChild(body)
+	body.SetName("soap", "Body")
+
+	res, err := envelope.Marshal(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body></soap:Body></soap:Envelope>` {
+		t.Fatal(res)
+	}
+	if Must(res).Equal(envelope) == false {
+		t.Fatal(`the built tree must be equal to the decoded one`)
+	}
+
+	// The default namespace
+	feed := NewElement("feed")
+	feed.DeclareNamespace("", "urn:old")
+	feed.DeclareNamespace("", "http://www.w3.org/2005/Atom")
+	feed.SetName("", "feed")
+	entry := NewElement("")
+	feed.AppendChild(entry)
+	entry.SetName("", "entry")
+
+	if res, _ = feed.Marshal(false, false); res != `<feed xmlns="http://www.w3.org/2005/Atom"><entry></entry></feed>` {
+		t.Fatal(res)
+	}
+	if entry.Name.Space != "http://www.w3.org/2005/Atom" {
+		t.Fatal(entry.Name)
+	}
+
+	entry.SetName("x", "entry")
+	if entry.Name.Space != "x" {
+		t.Fatal(`an undeclared prefix must be kept as Name.Space`)
+	}
+}
@@ -0,0 +1,199 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrInputTooLarge is returned when an input exceeds the size limit.
+	ErrInputTooLarge = errors.New("Input size limit exceeded")
+)
+
+// ParseError is returned by Parse and ParseString to locate where decoding failed.
+type ParseError struct {
+	// Err is the error from the decoder, e.g. *xml.SyntaxError.
+	Err error
+
+	// Line and Column are the 1-based position in the input where the decoder stopped.
+	// Column is counted in bytes.
+	Line, Column int
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", err.Line, err.Column, err.Err)
+}
+
+// Unwrap returns Err.
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+func newParseError(err error, data []byte, offset int64) *ParseError {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	before := data[:offset]
+	return &ParseError{
+		Err:    err,
+		Line:   bytes.Count(before, []byte{'\n'}) + 1,
+		Column: len(before) - bytes.LastIndexByte(before, '\n'),
+	}
+}
+
+// Parse decodes the root element of the XML document data into a new Element and returns it.
+// Unlike Must, it returns the error to the caller as *ParseError when data is malformed.
+//
+// In the same manner as xml.Unmarshal, the XML declaration, comments and whitespaces may precede
+// the root element, and data must contain a root element. Anything after the root element is not read.
+// Unlike xml.Unmarshal, CDATA sections are decoded as CData nodes.
+func Parse(data []byte) (*Element, error) {
+	return ParseWith(data, DecodeOptions{})
+}
+
+// ParseWith works like Parse, but it decodes according to opts. Use it with the limits such as
+// DecodeOptions.MaxDepth to parse untrusted input.
+func ParseWith(data []byte, opts DecodeOptions) (*Element, error) {
+	elem := opts.Pool.Get()
+	d := newReaderDecoder(bytes.NewReader(data), &opts)
+	if err := d.decodeRoot(elem); err != nil {
+		return nil, newParseError(err, data, d.InputOffset())
+	}
+	return elem, nil
+}
+
+// ParseString works like Parse, but it decodes the string s.
+func ParseString(s string) (*Element, error) {
+	return Parse([]byte(s))
+}
+
+// ParseReader works like Parse, but it decodes the document read from r while reading it, so the whole
+// document is never held in memory. Since the input is not kept, the error of the decoding,
+// e.g. *xml.SyntaxError with the line number, is returned unchanged rather than as *ParseError.
+func ParseReader(r io.Reader) (*Element, error) {
+	return parseRoot(r)
+}
+
+func parseRoot(r io.Reader) (*Element, error) {
+	elem := &Element{}
+	if err := newReaderDecoder(r, &DecodeOptions{}).decodeRoot(elem); err != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+// MustParse works like ParseString, but it panics if s is malformed. Unlike Must, which terminates the process,
+// the panic can be recovered. The panic value is an error wrapping the one returned by ParseString.
+func MustParse(s string) *Element {
+	elem, err := ParseString(s)
+	if err != nil {
+		panic(fmt.Errorf("dom: failed to parse %q: %w", s, err))
+	}
+	return elem
+}
+
+// DecodeAppend parses s as an XML fragment and appends the parsed nodes to Children.
+// Unlike UnmarshalXML, it keeps Name, Attr and the existing Children of elem as they are.
+// The fragment may contain any number of top-level elements, texts and comments, e.g. "<b/>text<c/>".
+// Nothing is appended if s is malformed.
+func (elem *Element) DecodeAppend(s string) error {
+	if elem == nil {
+		return nil
+	}
+
+	nodes, err := parseFragment(s)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		setParent(node, elem)
+	}
+	elem.Children = append(elem.Children, nodes...)
+	return nil
+}
+
+// SetInnerXML replaces Children with the nodes parsed from fragment, which may contain any number of
+// top-level elements, texts and comments in the same manner as DecodeAppend. It is the counterpart of InnerXML.
+// Children is left as it is if fragment is malformed. Parent of the replaced elements is cleared.
+func (elem *Element) SetInnerXML(fragment string) error {
+	if elem == nil {
+		return nil
+	}
+
+	nodes, err := parseFragment(fragment)
+	if err != nil {
+		return err
+	}
+
+	elem.TakeChildren()
+	for _, node := range nodes {
+		setParent(node, elem)
+	}
+	elem.Children = nodes
+	return nil
+}
+
+// parseFragment parses top-level nodes of s until EOF. Whitespace-only texts are ignored in the same
+// manner as UnmarshalXML.
+func parseFragment(s string) (res []Node, err error) {
+	d := newReaderDecoder(strings.NewReader(s), &DecodeOptions{})
+	for {
+		var next xml.Token
+		if next, err = d.token(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+
+		var node Node
+		if node, err = d.decodeNode(next); err != nil {
+			return nil, err
+		}
+
+		if node != nil {
+			res = append(res, node)
+		}
+	}
+}
+
+// ParseLimited decodes the root element read from r, but it reads at most maxBytes bytes from r.
+// It returns ErrInputTooLarge if the document continues beyond the limit, rather than
+// reporting a syntax error of the truncated input.
+func ParseLimited(r io.Reader, maxBytes int64) (*Element, error) {
+	elem := &Element{}
+	if err := newReaderDecoder(&limitedReader{r: r, n: maxBytes}, &DecodeOptions{}).decodeRoot(elem); err != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+// limitedReader works like io.LimitedReader, but it returns ErrInputTooLarge instead of io.EOF
+// when r has more data than the limit.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (n int, err error) {
+	if l.n <= 0 {
+		var b [1]byte
+		if n, err = l.r.Read(b[:]); n > 0 {
+			return 0, ErrInputTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err = l.r.Read(p)
+	l.n -= int64(n)
+	return
+}
@@ -0,0 +1,199 @@
+package dom
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAppend(t *testing.T) {
+	elem := Must(`<a x="1"><b/></a>`)
+	if err := elem.DecodeAppend(`<c/>text<!--comment--><d><e/></d>`); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a x="1"><b></b><c></c>text<!--comment--><d><e></e></d></a>` {
+		t.Fatal(res)
+	}
+
+	if err := elem.DecodeAppend(`<f/><g>`); err == nil {
+		t.Fatal(`DecodeAppend() must fail with malformed input`)
+	}
+	if len(elem.Children) != 5 {
+		t.Fatal(`elem must be unchanged on failure`)
+	}
+
+	// UnmarshalXML resets the element
+	if err := xml.Unmarshal([]byte(`<z/>`), elem); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<z></z>` {
+		t.Fatal(res)
+	}
+}
+
+func TestParseLimited(t *testing.T) {
+	input := `<a><b>text</b><c/></a>`
+
+	elem, err := ParseLimited(strings.NewReader(input), int64(len(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a><b>text</b><c></c></a>` {
+		t.Fatal(res)
+	}
+
+	if _, err = ParseLimited(strings.NewReader(input), int64(len(input)-1)); err != ErrInputTooLarge {
+		t.Fatal(err)
+	}
+
+	if _, err = ParseLimited(strings.NewReader(`<a><b>`), 100); err == nil || err == ErrInputTooLarge {
+		t.Fatal(err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	elem, err := Parse([]byte(`<?xml version="1.0"?><!--comment--><a x="1"><b/></a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a x="1"><b></b></a>` {
+		t.Fatal(res)
+	}
+	if elem.Children[0].(*Element).Parent != elem {
+		t.Fatal(`Parent must be set`)
+	}
+
+	if elem, err = Parse([]byte(`<a><b></a>`)); err == nil || elem != nil {
+		t.Fatal(`Parse() must fail with malformed input`)
+	}
+	if _, err = Parse([]byte(`<!--no root-->`)); err == nil {
+		t.Fatal(`Parse() must fail without root element`)
+	}
+
+	if elem, err = ParseString(`<a><![CDATA[<b>]]></a>`); err != nil || elem.Children[0] != CData("<b>") {
+		t.Fatal(`ParseString() must decode CDATA sections`)
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	if MustParse(`<a/>`).Name.Local != "a" {
+		t.Fatal(`MustParse(<a/>).Name.Local != "a"`)
+	}
+
+	defer func() {
+		err, ok := recover().(error)
+		if ok == false {
+			t.Fatal(`MustParse() must panic with an error`)
+		}
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) == false {
+			t.Fatal(err)
+		}
+	}()
+	MustParse(`<a><b></a>`)
+}
+
+func TestParseReader(t *testing.T) {
+	elem, err := ParseReader(strings.NewReader(`<a><b>text</b></a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a><b>text</b></a>` {
+		t.Fatal(res)
+	}
+
+	_, err = ParseReader(strings.NewReader("<a>\n<b>\n</a>"))
+	if err, ok := err.(*xml.SyntaxError); ok == false || err.Line != 3 {
+		t.Fatal(err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := ParseString("<a>\n  <b>\n    </c>\n  </b>\n</a>")
+	parseErr, ok := err.(*ParseError)
+	if ok == false {
+		t.Fatal(err)
+	}
+	if parseErr.Line != 3 || parseErr.Column != 9 {
+		t.Fatal(parseErr)
+	}
+
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) == false || syntaxErr.Line != parseErr.Line {
+		t.Fatal(`ParseError must wrap *xml.SyntaxError`)
+	}
+
+	if _, err = Parse([]byte("<a>\n<b>")); err.(*ParseError).Line != 2 {
+		t.Fatal(err)
+	}
+}
+
+func TestSetInnerXML(t *testing.T) {
+	elem := Must(`<a x="1"><old/></a>`)
+	old := elem.Children[0].(*Element)
+
+	if err := elem.SetInnerXML(`<b/>text<c><d/></c>`); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a x="1"><b></b>text<c><d></d></c></a>` {
+		t.Fatal(res)
+	}
+	if old.Parent != nil || elem.FindChild("c").Parent != elem {
+		t.Fatal(`Parent must be updated`)
+	}
+
+	if err := elem.SetInnerXML(`<e>`); err == nil {
+		t.Fatal(`SetInnerXML() must fail with malformed input`)
+	}
+	if len(elem.Children) != 3 {
+		t.Fatal(`elem must be unchanged on failure`)
+	}
+
+	if res, _ := elem.InnerXML(MarshalOptions{}); res != `<b></b>text<c><d></d></c>` {
+		t.Fatal(res)
+	}
+}
+
+func TestParseWithMaxDepth(t *testing.T) {
+	input := []byte(strings.Repeat("<a>", 4) + strings.Repeat("</a>", 4))
+
+	if _, err := ParseWith(input, DecodeOptions{MaxDepth: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseWith(input, DecodeOptions{MaxDepth: 3})
+	if errors.Is(err, ErrMaxDepth) == false {
+		t.Fatal(err)
+	}
+	if err.(*ParseError).Column != 13 {
+		t.Fatal(err)
+	}
+
+	if _, err = ParseWith(input, DecodeOptions{}); err != nil {
+		t.Fatal(`0 must mean unlimited`)
+	}
+}
+
+func TestParseWithLimits(t *testing.T) {
+	input := []byte(`<a><b/>text<!--comment--><c><d/></c></a>`)
+
+	if _, err := ParseWith(input, DecodeOptions{MaxNodes: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseWith(input, DecodeOptions{MaxNodes: 5}); errors.Is(err, ErrMaxNodes) == false {
+		t.Fatal(err)
+	}
+
+	wide := []byte("<a>" + strings.Repeat("<b/>", 1000) + "</a>")
+	if _, err := ParseWith(wide, DecodeOptions{MaxBytes: int64(len(wide))}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ParseWith(wide, DecodeOptions{MaxBytes: 100})
+	if errors.Is(err, ErrInputTooLarge) == false {
+		t.Fatal(err)
+	}
+	if err.(*ParseError).Line != 1 {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+package dom
+
+import "sync"
+
+// ParserPool recycles Element structs and their Children slices to reduce the allocations when
+// parsing many documents. Set it to DecodeOptions.Pool, or use its Parse method, to decode elements
+// drawn from the pool, and Put the tree back once it is no longer used.
+//
+// The zero value is ready to use, and a nil *ParserPool works without pooling. It is safe for concurrent use.
+type ParserPool struct {
+	pool sync.Pool
+}
+
+// Get returns an empty Element from the pool, or a new one if the pool has none.
+func (p *ParserPool) Get() *Element {
+	if p != nil {
+		if elem, ok := p.pool.Get().(*Element); ok == true {
+			return elem
+		}
+	}
+	return &Element{}
+}
+
+// Put resets elem and all of its descendant elements and returns them to the pool.
+// Neither elem nor any element of its tree may be used after Put, including the ones stored elsewhere
+// and the texts obtained from the tree without copying, since they may be reused by the subsequent parsing.
+// Put elem only once and only at the root of a tree no one else references. Nothing happens if elem is nil.
+func (p *ParserPool) Put(elem *Element) {
+	if p == nil || elem == nil {
+		return
+	}
+
+	for i, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			p.Put(childElem)
+		}
+		elem.Children[i] = nil
+	}
+
+	*elem = Element{Children: elem.Children[:0]}
+	p.pool.Put(elem)
+}
+
+// Parse works like Parse, but the elements of the returned tree are drawn from the pool.
+func (p *ParserPool) Parse(data []byte) (*Element, error) {
+	return ParseWith(data, DecodeOptions{Pool: p})
+}
@@ -0,0 +1,53 @@
+package dom
+
+import "testing"
+
+func TestParserPool(t *testing.T) {
+	const input = `<a x="1"><b>text</b><!--comment--><c><d/></c></a>`
+
+	var pool ParserPool
+	for i := 0; i < 3; i++ {
+		elem, err := pool.Parse([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elem.Equal(Must(input)) == false {
+			t.Fatal(elem.Marshal(false, false))
+		}
+		if c := elem.FindChild("c"); c.Parent != elem || c.FindChild("d").Parent != c {
+			t.Fatal(`Parent must be set`)
+		}
+
+		b := elem.FindChild("b")
+		pool.Put(elem)
+		if elem.IsEmpty() == false || len(elem.Name.Local) > 0 || b.IsEmpty() == false || b.Parent != nil {
+			t.Fatal(`Put must reset the elements`)
+		}
+	}
+
+	if res := pool.Get(); res == nil || res.IsEmpty() == false || len(res.Name.Local) > 0 {
+		t.Fatal(res)
+	}
+
+	if _, err := pool.Parse([]byte(`<a><b></a>`)); err == nil {
+		t.Fatal(`malformed input must fail`)
+	}
+
+	var nilPool *ParserPool
+	if res := nilPool.Get(); res == nil {
+		t.Fatal(`nil pool must allocate a new element`)
+	}
+	nilPool.Put(Must(input))
+}
+
+func TestUnmarshalWithPool(t *testing.T) {
+	var pool ParserPool
+	elem := pool.Get()
+	if err := UnmarshalWith([]byte(`<a><b/>text</a>`), elem, DecodeOptions{Pool: &pool}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<a><b></b>text</a>` {
+		t.Fatal(res)
+	}
+	pool.Put(elem)
+}
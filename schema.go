@@ -0,0 +1,102 @@
+package dom
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Schema is a declarative specification of the structure of an element, which is checked by Validate.
+// Schemas compose through Children to describe a whole document:
+//
+//	Schema{
+//		Name:     "PropertyGroup",
+//		Attr:     []string{"Condition"},
+//		Required: []string{"OutputPath"},
+//		Children: []Schema{{Name: "Item", Attr: []string{"Include"}}},
+//	}
+type Schema struct {
+	// Name is the expected Name.Local of the element. An empty Name matches any element.
+	Name string
+
+	// Attr lists the local names of the attributes the element must have.
+	Attr []string
+
+	// Required lists the local names of the child elements which must appear at least once.
+	Required []string
+
+	// Children holds the schemas of the child elements. Each schema is applied to every child element
+	// whose Name.Local equals its Name, or to every child element if its Name is empty. The child
+	// elements without a schema are not checked.
+	Children []Schema
+}
+
+// SchemaError describes a violation of a Schema found by Validate.
+type SchemaError struct {
+	// Path locates the offending element like "/PropertyGroup/OutputPath[2]", where the index is
+	// the 1-based position among the sibling elements with the same name.
+	Path string
+
+	// Msg describes the violation.
+	Msg string
+}
+
+func (err *SchemaError) Error() string {
+	return err.Path + ": " + err.Msg
+}
+
+// Validate checks elem and its descendants against s recursively, and returns all the violations
+// as *SchemaError in document order. It returns nil if elem conforms to s.
+func (elem *Element) Validate(s Schema) []error {
+	if elem == nil {
+		return []error{&SchemaError{Path: "/", Msg: "element is nil"}}
+	}
+
+	return elem.validate(nil, "/"+elem.Name.Local, &s)
+}
+
+func (elem *Element) validate(errs []error, path string, s *Schema) []error {
+	if len(s.Name) > 0 && elem.Name.Local != s.Name {
+		return append(errs, &SchemaError{Path: path, Msg: fmt.Sprintf("expected <%s>", s.Name)})
+	}
+
+	for _, name := range s.Attr {
+		if elem.HasAttr(name) == false {
+			errs = append(errs, &SchemaError{Path: path, Msg: fmt.Sprintf("missing attribute %s", name)})
+		}
+	}
+
+	counts := map[string]int{}
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			counts[childElem.Name.Local]++
+		}
+	}
+
+	for _, name := range s.Required {
+		if counts[name] == 0 {
+			errs = append(errs, &SchemaError{Path: path, Msg: fmt.Sprintf("missing child <%s>", name)})
+		}
+	}
+
+	indices := map[string]int{}
+	for _, child := range elem.Children {
+		childElem, ok := child.(*Element)
+		if ok == false {
+			continue
+		}
+
+		name := childElem.Name.Local
+		indices[name]++
+		for i := range s.Children {
+			if len(s.Children[i].Name) == 0 || s.Children[i].Name == name {
+				childPath := path + "/" + name
+				if counts[name] > 1 {
+					childPath += "[" + strconv.Itoa(indices[name]) + "]"
+				}
+				errs = childElem.validate(errs, childPath, &s.Children[i])
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,50 @@
+package dom
+
+import "testing"
+
+func TestValidateSchema(t *testing.T) {
+	s := Schema{
+		Name:     "PropertyGroup",
+		Attr:     []string{"Condition"},
+		Required: []string{"OutputPath"},
+		Children: []Schema{
+			{Name: "Item", Attr: []string{"id"}},
+		},
+	}
+
+	elem := Must(`<PropertyGroup Condition="x"><OutputPath>bin</OutputPath><Item id="1"/></PropertyGroup>`)
+	if errs := elem.Validate(s); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	elem = Must(`<PropertyGroup><Item id="1"/><Item/><Other/></PropertyGroup>`)
+	errs := elem.Validate(s)
+	if len(errs) != 3 {
+		t.Fatal(errs)
+	}
+	for i, expected := range []string{
+		`/PropertyGroup: missing attribute Condition`,
+		`/PropertyGroup: missing child <OutputPath>`,
+		`/PropertyGroup/Item[2]: missing attribute id`,
+	} {
+		if errs[i].Error() != expected {
+			t.Fatal(errs[i])
+		}
+	}
+
+	if errs = Must(`<Other/>`).Validate(s); len(errs) != 1 || errs[0].(*SchemaError).Path != "/Other" {
+		t.Fatal(errs)
+	}
+
+	// A child schema without Name is applied to every child element
+	any := Schema{Children: []Schema{{Attr: []string{"id"}}}}
+	errs = Must(`<list><a id="1"/><b/><a/></list>`).Validate(any)
+	if len(errs) != 2 || errs[0].Error() != `/list/b: missing attribute id` || errs[1].Error() != `/list/a[2]: missing attribute id` {
+		t.Fatal(errs)
+	}
+
+	elem = nil
+	if errs = elem.Validate(s); len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
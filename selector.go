@@ -0,0 +1,297 @@
+package dom
+
+import (
+	"errors"
+	"strings"
+)
+
+// selector is a parsed selector of QuerySelector, i.e. a list of compound selectors combined with
+// the descendant combinator.
+type selector []compoundSelector
+
+type compoundSelector struct {
+	name  string
+	attrs []attrSelector
+}
+
+type attrSelector struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+var (
+	// ErrInvalidSelector is returned when a selector cannot be parsed.
+	ErrInvalidSelector = errors.New("Invalid selector")
+)
+
+func parseSelector(s string) (res selector, err error) {
+	for s = strings.TrimSpace(s); len(s) > 0; s = strings.TrimLeft(s, " \t\r\n") {
+		var c compoundSelector
+		if c, s, err = parseCompoundSelector(s); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+
+	if len(res) == 0 {
+		return nil, ErrInvalidSelector
+	}
+	return
+}
+
+func parseCompoundSelector(s string) (res compoundSelector, rest string, err error) {
+	end := strings.IndexAny(s, "[ \t\r\n")
+	if end < 0 {
+		end = len(s)
+	}
+
+	if res.name = s[:end]; res.name != "*" && len(res.name) > 0 && isName(res.name) == false {
+		return res, "", ErrInvalidSelector
+	}
+
+	for rest = s[end:]; strings.HasPrefix(rest, "["); {
+		var attr attrSelector
+		if attr, rest, err = parseAttrSelector(rest[1:]); err != nil {
+			return
+		}
+		res.attrs = append(res.attrs, attr)
+	}
+
+	if len(res.name) == 0 && len(res.attrs) == 0 || len(rest) > 0 && strings.IndexAny(rest[:1], " \t\r\n") < 0 {
+		return res, "", ErrInvalidSelector
+	}
+	return
+}
+
+func parseAttrSelector(s string) (res attrSelector, rest string, err error) {
+	end := strings.IndexAny(s, "=]")
+	if end < 0 {
+		return res, "", ErrInvalidSelector
+	}
+
+	if res.name = strings.TrimSpace(s[:end]); isName(res.name) == false {
+		return res, "", ErrInvalidSelector
+	}
+
+	if s[end] == ']' {
+		return res, s[end+1:], nil
+	}
+
+	s = strings.TrimLeft(s[end+1:], " ")
+	res.hasValue = true
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		q := strings.IndexByte(s[1:], s[0])
+		if q < 0 {
+			return res, "", ErrInvalidSelector
+		}
+		res.value, s = s[1:q+1], strings.TrimLeft(s[q+2:], " ")
+		if strings.HasPrefix(s, "]") == false {
+			return res, "", ErrInvalidSelector
+		}
+		return res, s[1:], nil
+	}
+
+	if end = strings.IndexByte(s, ']'); end < 0 {
+		return res, "", ErrInvalidSelector
+	}
+	res.value = strings.TrimSpace(s[:end])
+	return res, s[end+1:], nil
+}
+
+func (c *compoundSelector) match(elem *Element) bool {
+	if len(c.name) > 0 && c.name != "*" && c.name != elem.Name.Local {
+		return false
+	}
+
+	for _, attr := range c.attrs {
+		found := elem.FindAttr(attr.name)
+		if found == nil || attr.hasValue == true && found.Value != attr.value {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns true if elem matches the last compound selector and the preceding ones
+// match the elements in path, which holds the ancestors of elem from the outermost one.
+func (sel selector) match(path []*Element, elem *Element) bool {
+	i := len(sel) - 1
+	if sel[i].match(elem) == false {
+		return false
+	}
+
+	for j := len(path) - 1; i > 0 && j >= 0; j-- {
+		if sel[i-1].match(path[j]) == true {
+			i--
+		}
+	}
+	return i == 0
+}
+
+// ReplaceSelector replaces each descendant element of elem matching selector with the nodes
+// parsed from xmlFragment, which may contain any number of top-level nodes (see DecodeAppend).
+// The descendants of a replaced element are not examined any further. It returns the number
+// of replaced elements.
+//
+// See QuerySelector for the syntax of selector. Nothing is changed if selector or xmlFragment is malformed.
+func (elem *Element) ReplaceSelector(selector, xmlFragment string) (res int, err error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return
+	}
+
+	if _, err = parseFragment(xmlFragment); err != nil || elem == nil {
+		return
+	}
+
+	res = elem.replaceSelector(make([]*Element, 0, 8), sel, xmlFragment)
+	return
+}
+
+func (elem *Element) replaceSelector(path []*Element, sel selector, fragment string) (res int) {
+	path = append(path, elem)
+
+	children := make([]Node, 0, len(elem.Children))
+	for _, child := range elem.Children {
+		childElem, ok := child.(*Element)
+		if ok == false {
+			children = append(children, child)
+			continue
+		}
+
+		if sel.match(path, childElem) == true {
+			// The fragment is known to be well-formed, so parsing it again cannot fail.
+			nodes, _ := parseFragment(fragment)
+			for _, node := range nodes {
+				setParent(node, elem)
+			}
+			childElem.Parent = nil
+			children = append(children, nodes...)
+			res++
+			continue
+		}
+
+		res += childElem.replaceSelector(path, sel, fragment)
+		children = append(children, child)
+	}
+
+	if res > 0 {
+		elem.Children = children
+	}
+	return
+}
+
+// QuerySelector returns the first descendant element of elem matching sel in document order
+// (depth-first preorder), or nil if nothing matches or sel is malformed.
+//
+// sel is a minimal subset of CSS selectors which consists of compound selectors separated by
+// whitespace (the descendant combinator). Each compound selector is an optional local name or "*",
+// followed by any number of attribute conditions:
+//
+//	item            elements whose Name.Local is "item"
+//	*               any element
+//	item[id]        <item> elements having an "id" attribute
+//	item[id=foo]    <item> elements whose "id" attribute is "foo" (the value may be quoted with ' or ")
+//	list item[id]   <item id="..."> elements which are descendants of a <list> element
+//
+// A compound selector must consist of at least a name or an attribute condition. The candidates are
+// the descendants of elem, while the ancestors in the descendant combinator may be elem itself.
+func (elem *Element) QuerySelector(sel string) (res *Element) {
+	parsed, err := parseSelector(sel)
+	if err != nil || elem == nil {
+		return
+	}
+
+	elem.WalkWithPath(func(path []*Element, e *Element) error {
+		if len(path) > 0 && parsed.match(path, e) == true {
+			res = e
+			return ErrBreak
+		}
+		return nil
+	})
+	return
+}
+
+// QuerySelectorAll works like QuerySelector, but it returns all the matching elements in document order,
+// including the ones nested in other matching elements.
+func (elem *Element) QuerySelectorAll(sel string) []*Element {
+	parsed, err := parseSelector(sel)
+	if err != nil {
+		return nil
+	}
+
+	return elem.querySelectorAll(parsed, true)
+}
+
+// querySelectorAll returns the descendant elements of elem matching sel in document order.
+// The descendants of a matched element are examined only when nested is true.
+func (elem *Element) querySelectorAll(sel selector, nested bool) []*Element {
+	if elem == nil {
+		return nil
+	}
+
+	return elem.appendMatches(nil, make([]*Element, 0, 8), sel, nested)
+}
+
+func (elem *Element) appendMatches(res, path []*Element, sel selector, nested bool) []*Element {
+	path = append(path, elem)
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			matched := sel.match(path, childElem)
+			if matched == true {
+				res = append(res, childElem)
+			}
+
+			if matched == false || nested == true {
+				res = childElem.appendMatches(res, path, sel, nested)
+			}
+		}
+	}
+	return res
+}
+
+// MarshalSelector returns the concatenated XML encodings of the descendant elements of elem matching
+// selector (see QuerySelector for the syntax) in document order. The descendants of a matched element
+// are not examined any further since they are included in its encoding.
+//
+// Each element is encoded according to opts in the same manner as MarshalOpts. The encodings are
+// separated by line feeds when opts specifies the indentation, and the XML declaration is written once
+// at the beginning when opts.WithDecl is true. It returns an empty string without error if nothing matches,
+// and a non-nil error if selector is malformed.
+func (elem *Element) MarshalSelector(selector string, opts MarshalOptions) (res string, err error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return
+	}
+
+	matches := elem.querySelectorAll(sel, false)
+	if len(matches) == 0 {
+		return
+	}
+
+	indented := opts.indented()
+
+	withDecl := opts.WithDecl
+	opts.WithDecl = false
+
+	var b strings.Builder
+	if withDecl == true {
+		b.WriteString(opts.decl() + "\n")
+	}
+
+	for i, match := range matches {
+		var s string
+		if s, err = match.marshal(opts); err != nil {
+			return "", err
+		}
+
+		if i > 0 && indented == true {
+			b.WriteByte('\n')
+		}
+		b.WriteString(s)
+	}
+
+	res = b.String()
+	return
+}
@@ -0,0 +1,115 @@
+package dom
+
+import "testing"
+
+func TestParseSelector(t *testing.T) {
+	for _, s := range []string{`item`, `*`, `item[id]`, `[id]`, `item[id=foo]`, `item[id="a b"][x]`, `list  item[ id = 'foo' ]`} {
+		if _, err := parseSelector(s); err != nil {
+			t.Fatalf(`parseSelector(%q) failed`, s)
+		}
+	}
+
+	for _, s := range []string{``, ` `, `1item`, `item[`, `item[id`, `item[id="foo]`, `item[]`, `item[id]x`, `item[id="a"x]`} {
+		if _, err := parseSelector(s); err != ErrInvalidSelector {
+			t.Fatalf(`parseSelector(%q) must fail`, s)
+		}
+	}
+}
+
+func TestReplaceSelector(t *testing.T) {
+	elem := Must(`<root><list><item id="foo"><item id="foo"/></item><item id="bar"/></list><item id="foo"/></root>`)
+
+	n, err := elem.ReplaceSelector(`list item[id=foo]`, `<new/>text`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf(`ReplaceSelector() == %d`, n)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<root><list><new></new>text<item id="bar"></item></list><item id="foo"></item></root>` {
+		t.Fatal(res)
+	}
+
+	if n, err = elem.ReplaceSelector(`item`, `<broken>`); err == nil || n != 0 {
+		t.Fatal(`ReplaceSelector() must fail with malformed fragment`)
+	}
+	if n, err = elem.ReplaceSelector(`item[`, `<new/>`); err != ErrInvalidSelector || n != 0 {
+		t.Fatal(`ReplaceSelector() must fail with malformed selector`)
+	}
+	if res, _ := elem.Marshal(false, false); res != `<root><list><new></new>text<item id="bar"></item></list><item id="foo"></item></root>` {
+		t.Fatal(`elem must be unchanged on failure`)
+	}
+
+	if n, _ = elem.ReplaceSelector(`root`, `<new/>`); n != 0 {
+		t.Fatal(`elem itself must not be replaced`)
+	}
+}
+
+func TestMarshalSelector(t *testing.T) {
+	elem := Must(`<response><error code="1">bad</error><ok/><group><error code="2"><error code="nested"/></error></group></response>`)
+
+	res, err := elem.MarshalSelector(`error`, MarshalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != `<error code="1">bad</error><error code="2"><error code="nested"></error></error>` {
+		t.Fatal(res)
+	}
+
+	res, err = elem.MarshalSelector(`group error`, MarshalOptions{Indent: " ", WithDecl: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="utf-8"?>
+<error code="2">
+ <error code="nested" />
+</error>`
+	if res != expected {
+		t.Fatal(res)
+	}
+
+	if res, err = elem.MarshalSelector(`warning`, MarshalOptions{}); err != nil || len(res) != 0 {
+		t.Fatal(`MarshalSelector() must return an empty string without error`)
+	}
+	if _, err = elem.MarshalSelector(`error[`, MarshalOptions{}); err != ErrInvalidSelector {
+		t.Fatal(`MarshalSelector() must fail with malformed selector`)
+	}
+}
+
+func TestQuerySelector(t *testing.T) {
+	elem := Must(`<root><item id="a"/><list><item/><item id="foo">1</item><group><item id="foo">2</item></group></list></root>`)
+
+	if res := elem.QuerySelector(`item`); res == nil || res.FindAttr("id").Value != "a" {
+		t.Fatal(`QuerySelector("item") must return the first <item>`)
+	}
+	if res := elem.QuerySelector(`list item[id]`); res == nil || res.TextRecurse() != "1" {
+		t.Fatal(`QuerySelector("list item[id]") failed`)
+	}
+	if res := elem.QuerySelector(`root group item[id=foo]`); res == nil || res.TextRecurse() != "2" {
+		t.Fatal(`QuerySelector("root group item[id=foo]") failed`)
+	}
+	if res := elem.QuerySelector(`group list item`); res != nil {
+		t.Fatal(`ancestors must match in order`)
+	}
+	if res := elem.QuerySelector(`root`); res != nil {
+		t.Fatal(`elem itself must not match`)
+	}
+	if res := elem.QuerySelector(`item[`); res != nil {
+		t.Fatal(`malformed selector must match nothing`)
+	}
+
+	if res := elem.QuerySelectorAll(`item[id=foo]`); len(res) != 2 {
+		t.Fatal(res)
+	}
+	if res := elem.QuerySelectorAll(`list *`); len(res) != 4 {
+		t.Fatal(res)
+	}
+	if res := Must(`<a><b><b/></b></a>`).QuerySelectorAll(`b`); len(res) != 2 {
+		t.Fatal(`nested matches must be included`)
+	}
+
+	elem = nil
+	if elem.QuerySelector(`item`) != nil || len(elem.QuerySelectorAll(`item`)) != 0 {
+		t.Fatal(`nil elem must match nothing`)
+	}
+}
@@ -0,0 +1,92 @@
+package dom
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TextInt parses the plain text of elem (see Text) as an int.
+// It returns def if elem has no plain text or the text cannot be parsed.
+func (elem *Element) TextInt(def int) int {
+	if text, ok := elem.Text(); ok == true {
+		if v, err := strconv.Atoi(strings.TrimSpace(text)); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// TextBool parses the plain text of elem (see Text) as a bool. "true", "false", "1" and "0" are
+// accepted case-insensitively. It returns def if elem has no plain text or the text cannot be parsed.
+func (elem *Element) TextBool(def bool) bool {
+	if text, ok := elem.Text(); ok == true {
+		if v, ok := parseBool(text); ok == true {
+			return v
+		}
+	}
+	return def
+}
+
+// TextFloat parses the plain text of elem (see Text) as a float64.
+// It returns def if elem has no plain text or the text cannot be parsed.
+func (elem *Element) TextFloat(def float64) float64 {
+	if text, ok := elem.Text(); ok == true {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+func parseBool(s string) (bool, bool) {
+	switch s = strings.TrimSpace(s); {
+	case s == "1" || strings.EqualFold(s, "true"):
+		return true, true
+	case s == "0" || strings.EqualFold(s, "false"):
+		return false, true
+	}
+	return false, false
+}
+
+// FindTextContaining returns elem and its descendant elements in document order that have
+// a direct xml.CharData or CData child containing sub.
+func (elem *Element) FindTextContaining(sub string) []*Element {
+	return elem.findText(func(text string) bool {
+		return strings.Contains(text, sub)
+	})
+}
+
+// FindTextMatching works like FindTextContaining, but it tests the direct text children with re.
+func (elem *Element) FindTextMatching(re *regexp.Regexp) []*Element {
+	return elem.findText(re.MatchString)
+}
+
+func (elem *Element) findText(match func(text string) bool) (res []*Element) {
+	if elem == nil {
+		return
+	}
+
+	elem.walk(func(e *Element) error {
+		for _, child := range e.Children {
+			if text, ok := isText(child); ok == true && match(text) == true {
+				res = append(res, e)
+				break
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// isText returns the content of n if n is xml.CharData or CData.
+func isText(n Node) (string, bool) {
+	switch text := n.(type) {
+	case xml.CharData:
+		return string(text), true
+	case CData:
+		return string(text), true
+	}
+	return "", false
+}
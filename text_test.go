@@ -0,0 +1,60 @@
+package dom
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTextTyped(t *testing.T) {
+	elem := Must(`<a><i>42</i><b>FALSE</b><f>2.5</f><x>oops</x><e/></a>`)
+	children := map[string]*Element{}
+	elem.ForEachChild(func(child *Element) error {
+		children[child.Name.Local] = child
+		return nil
+	})
+
+	if v := children["i"].TextInt(-1); v != 42 {
+		t.Fatalf(`TextInt() == %d`, v)
+	}
+	if v := children["b"].TextBool(true); v != false {
+		t.Fatal(`TextBool() == true`)
+	}
+	if v := children["f"].TextFloat(-1); v != 2.5 {
+		t.Fatalf(`TextFloat() == %f`, v)
+	}
+
+	for _, name := range []string{"x", "e"} {
+		child := children[name]
+		if child.TextInt(-1) != -1 || child.TextBool(true) != true || child.TextFloat(-1) != -1 {
+			t.Fatalf(`<%s> must return the default values`, name)
+		}
+	}
+
+	elem = nil
+	if elem.TextInt(7) != 7 || elem.TextBool(true) != true || elem.TextFloat(0.5) != 0.5 {
+		t.Fatal(`nil elem must return the default values`)
+	}
+}
+
+func TestFindTextContaining(t *testing.T) {
+	elem := Must(`<a>{{x}}<b>no</b><c><d>say {{x}}</d><!--{{x}}--></c><e>{{y}}</e></a>`)
+
+	res := elem.FindTextContaining("{{x}}")
+	if len(res) != 2 || res[0] != elem || res[1].Name.Local != "d" {
+		t.Fatal(res)
+	}
+
+	res = elem.FindTextMatching(regexp.MustCompile(`\{\{[a-z]\}\}`))
+	if len(res) != 3 || res[2].Name.Local != "e" {
+		t.Fatal(res)
+	}
+
+	if res = elem.FindTextContaining("{{z}}"); len(res) != 0 {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res = elem.FindTextContaining("{{x}}"); len(res) != 0 {
+		t.Fatal(res)
+	}
+}
@@ -0,0 +1,199 @@
+package dom
+
+// WalkWithPath invokes fn on elem and all of its descendant elements in document order (preorder).
+// path holds the ancestors of e from elem down to the immediate parent of e, so it is empty for elem itself.
+//
+// The backing array of path is reused between the invocations of fn, so copy it if it needs to be retained.
+// The traversal stops when fn returns ErrBreak, in which case this function returns nil.
+// Any other errors from fn stop the traversal immediately and are directly returned.
+func (elem *Element) WalkWithPath(fn func(path []*Element, e *Element) error) (err error) {
+	if elem == nil {
+		return
+	}
+
+	if err = elem.walkWithPath(make([]*Element, 0, 8), fn); err == ErrBreak {
+		err = nil
+	}
+	return
+}
+
+func (elem *Element) walkWithPath(path []*Element, fn func(path []*Element, e *Element) error) (err error) {
+	if err = fn(path, elem); err != nil {
+		return
+	}
+
+	path = append(path, elem)
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			if err = childElem.walkWithPath(path, fn); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Walk traverses elem and all of its descendant elements in document order. It invokes enter on each element
+// before its descendants (preorder) and exit after them (postorder). Either of enter and exit may be nil.
+//
+// ErrBreak works differently for enter and exit:
+//   - When enter returns ErrBreak, the descendants of the element are skipped, but exit is still invoked
+//     on the element and the traversal continues with its next sibling.
+//   - When exit returns ErrBreak, the whole traversal stops and this function returns nil.
+//
+// Any other errors from enter or exit stop the traversal immediately and are directly returned.
+func (elem *Element) Walk(enter func(e *Element) error, exit func(e *Element) error) (err error) {
+	if elem == nil {
+		return
+	}
+
+	if err = elem.walkEnterExit(enter, exit); err == ErrBreak {
+		err = nil
+	}
+	return
+}
+
+func (elem *Element) walkEnterExit(enter func(e *Element) error, exit func(e *Element) error) (err error) {
+	skip := false
+	if enter != nil {
+		if err = enter(elem); err == ErrBreak {
+			skip = true
+		} else if err != nil {
+			return
+		}
+	}
+
+	if skip == false {
+		for _, child := range elem.Children {
+			if childElem, ok := child.(*Element); ok == true {
+				if err = childElem.walkEnterExit(enter, exit); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	if exit != nil {
+		err = exit(elem)
+	} else {
+		err = nil
+	}
+	return
+}
+
+// ElementsAtDepth returns the descendant elements exactly d levels below elem in document order.
+// The depth is counted from elem itself, i.e. d == 0 returns elem, d == 1 returns the child elements,
+// d == 2 returns the grandchild elements and so on. It returns an empty slice if d is negative.
+func (elem *Element) ElementsAtDepth(d int) (res []*Element) {
+	if elem == nil || d < 0 {
+		return
+	}
+
+	if d == 0 {
+		return []*Element{elem}
+	}
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			res = append(res, childElem.ElementsAtDepth(d-1)...)
+		}
+	}
+	return
+}
+
+// walk invokes fn on elem and all of its descendant elements in document order (preorder).
+// It stops as soon as fn returns an error and returns the error as it is.
+func (elem *Element) walk(fn func(e *Element) error) (err error) {
+	if err = fn(elem); err != nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			if err = childElem.walk(fn); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// NameFrequency returns the number of the occurrences of each Name.Local in the subtree of elem,
+// including elem itself.
+func (elem *Element) NameFrequency() map[string]int {
+	res := map[string]int{}
+	if elem == nil {
+		return res
+	}
+
+	elem.walk(func(e *Element) error {
+		res[e.Name.Local]++
+		return nil
+	})
+	return res
+}
+
+// FindDescendant returns the first descendant element whose Name.Local is name in document order
+// (depth-first preorder), or nil if there is no such element. elem itself is not considered.
+func (elem *Element) FindDescendant(name string) (res *Element) {
+	elem.walkDescendants(func(e *Element) error {
+		if e.Name.Local == name {
+			res = e
+			return ErrBreak
+		}
+		return nil
+	})
+	return
+}
+
+// FindDescendants returns all the descendant elements whose Name.Local is name in document order.
+// elem itself is not considered.
+func (elem *Element) FindDescendants(name string) (res []*Element) {
+	elem.walkDescendants(func(e *Element) error {
+		if e.Name.Local == name {
+			res = append(res, e)
+		}
+		return nil
+	})
+	return
+}
+
+// walkDescendants works like walk, but it does not invoke fn on elem itself.
+func (elem *Element) walkDescendants(fn func(e *Element) error) (err error) {
+	if elem == nil {
+		return
+	}
+
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			if err = childElem.walk(fn); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// GetElementByID returns the first element whose "id" attribute is id in the subtree of elem
+// in document order, including elem itself. It returns nil if there is no such element.
+// Use GetElementByAttr if the ID attribute has another name such as "xml:id" or "name".
+func (elem *Element) GetElementByID(id string) *Element {
+	return elem.GetElementByAttr("id", id)
+}
+
+// GetElementByAttr returns the first element which has the attribute name with value in the subtree
+// of elem in document order, including elem itself. It returns nil if there is no such element.
+func (elem *Element) GetElementByAttr(name, value string) (res *Element) {
+	if elem == nil {
+		return
+	}
+
+	elem.walk(func(e *Element) error {
+		if attr := e.FindAttr(name); attr != nil && attr.Value == value {
+			res = e
+			return ErrBreak
+		}
+		return nil
+	})
+	return
+}
@@ -0,0 +1,185 @@
+package dom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalkWithPath(t *testing.T) {
+	elem := Must(`<root><config><value>1</value></config><other><value>2</value></other><config><x><value>3</value></x></config></root>`)
+
+	var names []string
+	var values []string
+	err := elem.WalkWithPath(func(path []*Element, e *Element) error {
+		names = append(names, e.Name.Local)
+		if e.Name.Local == "value" && path[len(path)-1].Name.Local == "config" {
+			text, _ := e.Text()
+			values = append(values, text)
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 8 || names[0] != "root" || names[1] != "config" || names[7] != "value" {
+		t.Fatal(names)
+	}
+	if len(values) != 1 || values[0] != "1" {
+		t.Fatal(values)
+	}
+
+	count := 0
+	err = elem.WalkWithPath(func(path []*Element, e *Element) error {
+		count++
+		if len(path) == 2 {
+			return ErrBreak
+		}
+		return nil
+	})
+	if err != nil || count != 3 {
+		t.Fatal(`WalkWithPath with ErrBreak failed.`)
+	}
+
+	errTest := errors.New("test")
+	if err = elem.WalkWithPath(func(path []*Element, e *Element) error { return errTest }); err != errTest {
+		t.Fatal(`err != errTest`)
+	}
+
+	elem = nil
+	if err = elem.WalkWithPath(func(path []*Element, e *Element) error { return errTest }); err != nil {
+		t.Fatal(`nil elem must not invoke fn`)
+	}
+}
+
+func TestElementsAtDepth(t *testing.T) {
+	elem := Must(`<table><tr><td>1</td><td>2</td></tr>text<tr><td>3</td></tr></table>`)
+
+	if res := elem.ElementsAtDepth(0); len(res) != 1 || res[0] != elem {
+		t.Fatal(`ElementsAtDepth(0) must return elem`)
+	}
+	if res := elem.ElementsAtDepth(1); len(res) != 2 || res[0].Name.Local != "tr" {
+		t.Fatal(`ElementsAtDepth(1) must return two <tr>`)
+	}
+
+	res := elem.ElementsAtDepth(2)
+	if len(res) != 3 {
+		t.Fatal(`ElementsAtDepth(2) must return three <td>`)
+	}
+	for i, want := range []string{"1", "2", "3"} {
+		if text, _ := res[i].Text(); text != want {
+			t.Fatal(text)
+		}
+	}
+
+	if len(elem.ElementsAtDepth(3)) != 0 || len(elem.ElementsAtDepth(-1)) != 0 {
+		t.Fatal(`ElementsAtDepth() must return an empty slice`)
+	}
+
+	elem = nil
+	if len(elem.ElementsAtDepth(0)) != 0 {
+		t.Fatal(`nil elem must return an empty slice`)
+	}
+}
+
+func TestNameFrequency(t *testing.T) {
+	elem := Must(`<a><b/><c><b/><b/></c>text<a/></a>`)
+	res := elem.NameFrequency()
+	if len(res) != 3 || res["a"] != 2 || res["b"] != 3 || res["c"] != 1 {
+		t.Fatal(res)
+	}
+
+	elem = nil
+	if res = elem.NameFrequency(); res == nil || len(res) != 0 {
+		t.Fatal(`nil elem must return an empty map`)
+	}
+}
+
+func TestFindDescendant(t *testing.T) {
+	elem := Must(`<a><b><a id="1"/></b><c><a id="2"><a id="3"/></a></c></a>`)
+
+	if res := elem.FindDescendant("a"); res == nil || res.FindAttr("id").Value != "1" {
+		t.Fatal(`elem.FindDescendant("a") must return the first descendant <a>`)
+	}
+	if res := elem.FindDescendant("z"); res != nil {
+		t.Fatal(`elem.FindDescendant("z") != nil`)
+	}
+
+	res := elem.FindDescendants("a")
+	if len(res) != 3 {
+		t.Fatal(len(res))
+	}
+	for i, id := range []string{"1", "2", "3"} {
+		if res[i].FindAttr("id").Value != id {
+			t.Fatal(res[i].FindAttr("id").Value)
+		}
+	}
+
+	elem = nil
+	if elem.FindDescendant("a") != nil || elem.FindDescendants("a") != nil {
+		t.Fatal(`nil must not have descendants`)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	elem := Must(`<a><b><c/></b><d><e/></d><f/></a>`)
+
+	var events []string
+	err := elem.Walk(
+		func(e *Element) error {
+			events = append(events, "+"+e.Name.Local)
+			if e.Name.Local == "b" {
+				return ErrBreak
+			}
+			return nil
+		},
+		func(e *Element) error {
+			events = append(events, "-"+e.Name.Local)
+			if e.Name.Local == "e" {
+				return ErrBreak
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(events, " ") != "+a +b -b +d +e -e" {
+		t.Fatal(events)
+	}
+
+	errTest := errors.New("test")
+	last := ""
+	err = elem.Walk(func(e *Element) error {
+		last = e.Name.Local
+		if e.Name.Local == "c" {
+			return errTest
+		}
+		return nil
+	}, nil)
+	if err != errTest || last != "c" {
+		t.Fatal(err)
+	}
+}
+
+func TestGetElementByID(t *testing.T) {
+	elem := Must(`<html id="root"><body><div id="main"><p id="x" name="first"/></div><p id="x" name="second"/></body></html>`)
+
+	if res := elem.GetElementByID("root"); res != elem {
+		t.Fatal(`elem itself must be considered`)
+	}
+	if res := elem.GetElementByID("x"); res == nil || res.FindAttr("name").Value != "first" {
+		t.Fatal(`the first element in document order must be returned`)
+	}
+	if res := elem.GetElementByID("missing"); res != nil {
+		t.Fatal(`elem.GetElementByID("missing") != nil`)
+	}
+	if res := elem.GetElementByAttr("name", "second"); res == nil || res.Name.Local != "p" {
+		t.Fatal(`elem.GetElementByAttr("name", "second") must return the second <p>`)
+	}
+
+	elem = nil
+	if elem.GetElementByID("x") != nil {
+		t.Fatal(`nil.GetElementByID("x") != nil`)
+	}
+}
@@ -0,0 +1,84 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ValidateAttr returns an error if elem has the attribute name whose value does not match re.
+// A missing attribute is not an error. The error message includes the name of elem and the value.
+func (elem *Element) ValidateAttr(name string, re *regexp.Regexp) error {
+	if attr := elem.FindAttr(name); attr != nil && re.MatchString(attr.Value) == false {
+		return fmt.Errorf(`<%s %s="%s">: attribute value does not match %s`, elem.Name.Local, name, attr.Value, re)
+	}
+	return nil
+}
+
+// ValidateStructure checks the structural constraints which the marshal functions or strict consumers
+// rely on, and returns the first violation in document order as *SchemaError, or nil if there is none.
+// (It is not named Validate, which checks elem against a Schema.) The constraints are as follows:
+//   - Name.Local of each element is not empty.
+//   - No element has more than one attribute with the same Name.
+//   - Attribute values are valid UTF-8.
+//   - Comment texts neither contain "--" nor end with '-'.
+//
+// Path of the error locates the offending node like "/a/b[2]/@id" or "/a/comment()".
+func (elem *Element) ValidateStructure() error {
+	if elem == nil {
+		return nil
+	}
+
+	return elem.validateStructure("/" + elem.Name.Local)
+}
+
+func (elem *Element) validateStructure(path string) error {
+	if len(elem.Name.Local) == 0 {
+		return &SchemaError{Path: path, Msg: "empty element name"}
+	}
+
+	dup := duplicateAttr(elem.Attr)
+	for i, attr := range elem.Attr {
+		if i == dup {
+			return &SchemaError{Path: path + "/@" + attr.Name.Local, Msg: "duplicate attribute"}
+		}
+
+		if utf8.ValidString(attr.Value) == false {
+			return &SchemaError{Path: path + "/@" + attr.Name.Local, Msg: "invalid UTF-8 in attribute value"}
+		}
+	}
+
+	counts := map[string]int{}
+	for _, child := range elem.Children {
+		if childElem, ok := child.(*Element); ok == true {
+			counts[childElem.Name.Local]++
+		}
+	}
+
+	indices := map[string]int{}
+	for _, child := range elem.Children {
+		switch node := child.(type) {
+		case xml.Comment:
+			if bytes.Contains(node, []byte("--")) == true || bytes.HasSuffix(node, []byte("-")) == true {
+				return &SchemaError{Path: path + "/comment()", Msg: `comment contains "--" or ends with '-'`}
+			}
+		case *Element:
+			name := node.Name.Local
+			indices[name]++
+
+			childPath := path + "/" + name
+			if counts[name] > 1 {
+				childPath += "[" + strconv.Itoa(indices[name]) + "]"
+			}
+
+			if err := node.validateStructure(childPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package dom
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValidateAttr(t *testing.T) {
+	re := regexp.MustCompile(`^\d+\.\d+$`)
+	elem := Must(`<package version="1.2" bad="1.x"/>`)
+
+	if err := elem.ValidateAttr("version", re); err != nil {
+		t.Fatal(err)
+	}
+	if err := elem.ValidateAttr("missing", re); err != nil {
+		t.Fatal(err)
+	}
+
+	err := elem.ValidateAttr("bad", re)
+	if err == nil {
+		t.Fatal(`ValidateAttr() must fail`)
+	}
+	if strings.Contains(err.Error(), `<package bad="1.x">`) == false {
+		t.Fatal(err)
+	}
+
+	elem = nil
+	if err = elem.ValidateAttr("version", re); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateStructure(t *testing.T) {
+	elem := Must(`<a xmlns:x="urn:x" x="1" x:x="2"><b/><b><!--ok--><c/></b></a>`)
+	if err := elem.ValidateStructure(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mutate func(elem *Element)
+		path   string
+	}{
+		{func(elem *Element) { elem.FindChild("b").Name.Local = "" }, "/a/"},
+		{func(elem *Element) { elem.SetAttr("y", "1"); elem.Attr = append(elem.Attr, elem.Attr[3]) }, "/a/@y"},
+		{func(elem *Element) { elem.FindDescendant("c").SetAttr("v", "\xff") }, "/a/b[2]/c/@v"},
+		{func(elem *Element) { elem.ChildElements()[1].Children[0] = xml.Comment("a--b") }, "/a/b[2]/comment()"},
+	}
+
+	for _, test := range tests {
+		elem := elem.Clone()
+		test.mutate(elem)
+
+		err, ok := elem.ValidateStructure().(*SchemaError)
+		if ok == false || err.Path != test.path {
+			t.Fatal(err)
+		}
+	}
+}
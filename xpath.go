@@ -0,0 +1,224 @@
+package dom
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// xpath is a parsed expression of XPath.
+type xpath struct {
+	absolute bool
+	steps    []xpathStep
+}
+
+type xpathStep struct {
+	// descendant is true if the step follows "//".
+	descendant bool
+
+	// name is a local name, "*", "." or "..".
+	name  string
+	preds []xpathPred
+}
+
+// xpathPred is either a positional predicate like [2] or an attribute predicate like [@id='x'].
+type xpathPred struct {
+	position int
+	attr     attrSelector
+}
+
+func parseXPath(s string) (res xpath, err error) {
+	s = strings.TrimSpace(s)
+	res.absolute = strings.HasPrefix(s, "/")
+	if res.absolute == false {
+		s = "/" + s
+	}
+
+	for len(s) > 0 {
+		var step xpathStep
+		switch {
+		case strings.HasPrefix(s, "//"):
+			step.descendant, s = true, s[2:]
+		case strings.HasPrefix(s, "/"):
+			s = s[1:]
+		default:
+			return res, ErrInvalidSelector
+		}
+
+		end := strings.IndexAny(s, "/[")
+		if end < 0 {
+			end = len(s)
+		}
+
+		switch step.name, s = s[:end], s[end:]; step.name {
+		case "*", ".", "..":
+		default:
+			if isName(step.name) == false {
+				return res, ErrInvalidSelector
+			}
+		}
+
+		for strings.HasPrefix(s, "[") {
+			var pred xpathPred
+			if pred, s, err = parseXPathPred(s[1:]); err != nil {
+				return
+			}
+			step.preds = append(step.preds, pred)
+		}
+
+		res.steps = append(res.steps, step)
+	}
+
+	if len(res.steps) == 0 {
+		return res, ErrInvalidSelector
+	}
+	return
+}
+
+func parseXPathPred(s string) (res xpathPred, rest string, err error) {
+	if strings.HasPrefix(s, "@") {
+		// The attribute predicate has the same syntax as the attribute selector except for '@'.
+		res.attr, rest, err = parseAttrSelector(s[1:])
+		return
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return res, "", ErrInvalidSelector
+	}
+
+	if res.position, err = strconv.Atoi(strings.TrimSpace(s[:end])); err != nil || res.position < 1 {
+		return res, "", ErrInvalidSelector
+	}
+	return res, s[end+1:], nil
+}
+
+// XPath returns the elements selected by expr from elem in document order, or nil if nothing is
+// selected or expr is malformed. expr is a limited subset of XPath location paths:
+//
+//	/root/item       absolute path from the root element of the tree (found by Parent)
+//	item, ./item     relative path from elem
+//	..               the parent element
+//	//item           <item> elements at any depth; "a//b" selects the descendants <b> of <a>
+//	*                any element
+//	item[2]          the second <item> child of each parent (1-based)
+//	item[@id]        <item> elements having an "id" attribute
+//	item[@id='x']    <item> elements whose "id" attribute is "x" (the value may be quoted with ' or ")
+//
+// Predicates can be chained like item[@type='a'][2], where each one filters the result of the previous one.
+// Names are compared with Name.Local, and the other axes, functions and operators are not supported.
+func (elem *Element) XPath(expr string) []*Element {
+	parsed, err := parseXPath(expr)
+	if err != nil || elem == nil {
+		return nil
+	}
+
+	root := elem
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	// The document node, whose only child is the root element, is the context of an absolute path.
+	doc := &Element{Children: []Node{root}}
+
+	context := []*Element{elem}
+	if parsed.absolute == true {
+		context = []*Element{doc}
+	}
+
+	rank := map[*Element]int{}
+	doc.walk(func(e *Element) error {
+		rank[e] = len(rank)
+		return nil
+	})
+
+	for _, step := range parsed.steps {
+		context = step.eval(context, rank)
+	}
+
+	res := context[:0]
+	for _, e := range context {
+		if e != doc {
+			res = append(res, e)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// XPathFirst returns the first element of XPath(expr) in document order, or nil if nothing is selected.
+func (elem *Element) XPathFirst(expr string) *Element {
+	if res := elem.XPath(expr); len(res) > 0 {
+		return res[0]
+	}
+	return nil
+}
+
+// eval applies the step to each element in context, and returns the distinct results in document order.
+func (step *xpathStep) eval(context []*Element, rank map[*Element]int) (res []*Element) {
+	if step.descendant == true {
+		var all []*Element
+		for _, e := range context {
+			e.walk(func(e *Element) error {
+				all = append(all, e)
+				return nil
+			})
+		}
+		context = all
+	}
+
+	seen := map[*Element]bool{}
+	for _, e := range context {
+		var candidates []*Element
+		switch step.name {
+		case ".":
+			candidates = []*Element{e}
+		case "..":
+			if e.Parent != nil {
+				candidates = []*Element{e.Parent}
+			}
+		default:
+			for _, child := range e.ChildElements() {
+				if step.name == "*" || child.Name.Local == step.name {
+					candidates = append(candidates, child)
+				}
+			}
+		}
+
+		for _, pred := range step.preds {
+			candidates = pred.filter(candidates)
+		}
+
+		for _, candidate := range candidates {
+			if seen[candidate] == false {
+				seen[candidate] = true
+				res = append(res, candidate)
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return rank[res[i]] < rank[res[j]]
+	})
+	return
+}
+
+func (pred *xpathPred) filter(candidates []*Element) (res []*Element) {
+	if pred.position > 0 {
+		if pred.position <= len(candidates) {
+			res = candidates[pred.position-1 : pred.position]
+		}
+		return
+	}
+
+	c := compoundSelector{attrs: []attrSelector{pred.attr}}
+	for _, candidate := range candidates {
+		if c.match(candidate) == true {
+			res = append(res, candidate)
+		}
+	}
+	return
+}
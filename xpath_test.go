@@ -0,0 +1,62 @@
+package dom
+
+import (
+	"strings"
+	"testing"
+)
+
+func xpathIDs(res []*Element) string {
+	ids := make([]string, len(res))
+	for i, e := range res {
+		ids[i], _ = e.GetAttr("id")
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestXPath(t *testing.T) {
+	root := Must(`<root id="r"><list id="l1"><item id="1" type="a"/><item id="2" type="b"/><item id="3" type="a"><item id="3.1"/></item></list><list id="l2"><item id="4" type="a"/></list></root>`)
+	list := root.FindChild("list")
+
+	tests := []struct {
+		elem *Element
+		expr string
+		ids  string
+	}{
+		{root, `/root`, "r"},
+		{list, `/root/list`, "l1,l2"},
+		{root, `list/item`, "1,2,3,4"},
+		{root, `./list/item[2]`, "2"},
+		{root, `list/item[1]`, "1,4"},
+		{root, `list/item[@type='a']`, "1,3,4"},
+		{root, `list/item[@type="a"][2]`, "3"},
+		{root, `list/*[@id=2]`, "2"},
+		{root, `//item`, "1,2,3,3.1,4"},
+		{root, `//item[1]`, "1,3.1,4"},
+		{list, `//list[2]/item`, "4"},
+		{list, `item[3]/item`, "3.1"},
+		{list, `item/..`, "l1"},
+		{list, `.`, "l1"},
+		{root, `list[@id='l2']//item[@type]`, "4"},
+		{root, `/missing`, ""},
+		{root, `list/item[@id]/item[@type]`, ""},
+	}
+
+	for _, test := range tests {
+		if res := xpathIDs(test.elem.XPath(test.expr)); res != test.ids {
+			t.Fatal(test.expr, res)
+		}
+	}
+
+	for _, expr := range []string{``, `/`, `list/`, `list[`, `list[0]`, `list[@]`, `list[x]`, `a b`} {
+		if res := root.XPath(expr); res != nil {
+			t.Fatal(expr)
+		}
+	}
+
+	if res := root.XPathFirst(`//item[@type='a']`); res == nil || res.FindAttr("id").Value != "1" {
+		t.Fatal(`root.XPathFirst() must return the first match`)
+	}
+	if res := root.XPathFirst(`//missing`); res != nil {
+		t.Fatal(`root.XPathFirst("//missing") != nil`)
+	}
+}